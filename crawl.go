@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"carrierleads.com/internal/crawler"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+func runCrawl(ctx context.Context, config Config, args []string) error {
+	fs := flag.NewFlagSet("crawl", flag.ExitOnError)
+	mode := fs.String("mode", "full", "crawl mode, either full, refresh, failures, list, dockets or discover")
+	restart := fs.Bool("restart", false, "discard the saved checkpoint and crawl from the first dot or docket number")
+	input := fs.String("input", "-", "file listing the numbers to crawl in list mode, - for stdin")
+	column := fs.String("column", "", "CSV column holding the numbers in list mode, one number per line if empty")
+	kind := fs.String("kind", "dot", "kind of the numbers without a DOT or MC prefix in list mode, either dot or mc")
+	fs.IntVar(&config.DOTWatermark, "dot-watermark", config.DOTWatermark, "dot number the upper bound is probed from")
+	fs.IntVar(&config.BucketSize, "bucket-size", config.BucketSize, "dot numbers crawled past the upper bound before stopping")
+	fs.StringVar(&config.RefreshOrder, "refresh-order", config.RefreshOrder, "refresh order, either created_at or latest_update_time")
+	fs.DurationVar(&config.RefreshMaxAge, "refresh-max-age", config.RefreshMaxAge, "age of the carriers refreshed")
+	fs.IntVar(&config.RefreshBudget, "refresh-budget", config.RefreshBudget, "requests made to SAFER by a refresh, retries included, 0 for no limit")
+	fs.IntVar(&config.DocketStart, "docket-start", config.DocketStart, "first docket number crawled in dockets mode")
+	fs.IntVar(&config.DocketEnd, "docket-end", config.DocketEnd, "last docket number crawled in dockets mode")
+	fs.IntVar(&config.WriteBatchSize, "write-batch-size", config.WriteBatchSize, "carriers written to the database per batch, 1 to write them one by one")
+	fs.StringVar(&config.MetricsAddr, "metrics-addr", config.MetricsAddr, "address serving the Prometheus metrics at /metrics, none if empty")
+	parseFlags(fs, &config, args, 0, "crawl [flags]")
+	if config.MetricsAddr != "" {
+		l, err := serveMetrics(config.MetricsAddr)
+		if err != nil {
+			return err
+		}
+		defer l.Close()
+	}
+
+	c := newCrawler(config)
+	var crawl func() error
+	switch *mode {
+	case "full":
+		crawl = func() error {
+			return c.CrawlSafer(ctx, config.DOTWatermark, config.BucketSize, config.EmptyReprobe, *restart)
+		}
+	case "refresh":
+		crawl = func() error {
+			return c.RefreshSafer(ctx, crawler.RefreshOrder(config.RefreshOrder), config.RefreshMaxAge, config.RefreshBudget)
+		}
+	case "failures":
+		crawl = func() error { return c.DrainFailures(ctx) }
+	case "dockets":
+		crawl = func() error {
+			return c.CrawlDockets(ctx, config.DocketStart, config.DocketEnd, config.DocketMaxAge, *restart)
+		}
+	case "discover":
+		crawl = func() error {
+			return c.DiscoverByName(ctx, crawler.SearchKeywords(config.Keywords, config.PrefixLength))
+		}
+	case "list":
+		crawl = func() error { return crawlList(ctx, c, *input, *column, crawler.IdentifierKind(*kind)) }
+	default:
+		return fmt.Errorf("unknown mode %q", *mode)
+	}
+	if config.DBUrl == "" && *mode != "full" && *mode != "list" {
+		return fmt.Errorf("%s mode needs a database: %w", *mode, errNoDatabase)
+	}
+
+	hooks, err := webhooks(config)
+	if err != nil {
+		return err
+	}
+	if len(hooks) > 0 {
+		c.Notifier = crawler.NewNotifier(c.Dao, hooks)
+		// the sink is closed first, its last writes notify events
+		defer c.Notifier.Close()
+	}
+	sink, err := openSink(config, c)
+	if err != nil {
+		return err
+	}
+	c.Sink = sink
+	run, err := c.StartRun(ctx, *mode)
+	if err != nil {
+		c.Sink.Close()
+		return err
+	}
+	err = crawl()
+	if closeErr := c.Sink.Close(); err == nil {
+		err = closeErr
+	}
+	// the run is recorded even when ctx is done
+	if finishErr := run.Finish(context.Background(), err); finishErr != nil {
+		log.WithError(finishErr).WithField("run", run.ID).Error("failed to record the crawl run")
+	}
+	if errors.Is(err, context.Canceled) {
+		log.WithField("mode", *mode).Info("crawl interrupted, progress saved")
+	}
+	return err
+}
+
+// serveMetrics serves the Prometheus metrics at /metrics and the expvars at
+// /debug/vars in the background, until the listener it returns is closed. It fails
+// if addr can't be listened on.
+func serveMetrics(addr string) (net.Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics server: %w", err)
+	}
+	http.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.Serve(l, nil); !errors.Is(err, net.ErrClosed) {
+			log.WithError(err).Error("metrics server stopped")
+		}
+	}()
+	return l, nil
+}
+
+func crawlList(ctx context.Context, c *crawler.Crawler, input, column string, kind crawler.IdentifierKind) error {
+	if kind != crawler.DOTIdentifier && kind != crawler.MCMXIdentifier {
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+	r := os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	ids, invalid, err := crawler.ReadIdentifiers(r, column, kind)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"numbers": len(ids), "invalid": len(invalid)}).Info("crawling list")
+
+	summary, err := c.CrawlList(ctx, ids)
+	summary.Invalid = invalid
+	fmt.Printf("%d hits, %d misses, %d failures, %d invalid\n", summary.Hits, len(summary.Missed), len(summary.Failed), len(summary.Invalid))
+	for _, id := range summary.Missed {
+		fmt.Println("miss", id)
+	}
+	for _, id := range summary.Failed {
+		fmt.Println("failed", id)
+	}
+	for _, entry := range summary.Invalid {
+		fmt.Println("invalid", entry)
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestServeMetrics_addressInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if m, err := serveMetrics(l.Addr().String()); err == nil {
+		m.Close()
+		t.Error("serveMetrics() on an address in use should fail")
+	}
+}
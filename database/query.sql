@@ -1,4 +1,195 @@
 -- name: CreateSaferSnapshot :execresult
-REPLACE INTO fmcsa_carrier_safer (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at)
+REPLACE INTO fmcsa_carrier_safer (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date)
 VALUES
-	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
\ No newline at end of file
+	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: MarkSnapshotInactive :exec
+INSERT INTO fmcsa_carrier_safer (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date)
+VALUES
+	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE operating_status = VALUES(operating_status), inactive_date = COALESCE(inactive_date, VALUES(inactive_date)), created_at = VALUES(created_at);
+
+-- name: GetCrawlCheckpoint :one
+SELECT name, watermark, pending, updated_at FROM crawl_checkpoint
+WHERE name = ?;
+
+-- name: SaveCrawlCheckpoint :exec
+REPLACE INTO crawl_checkpoint (name, watermark, pending, updated_at)
+VALUES
+	(?, ?, ?, ?);
+
+-- name: DeleteCrawlCheckpoint :exec
+DELETE FROM crawl_checkpoint
+WHERE name = ?;
+
+-- name: ListSnapshotsByCreatedAt :many
+SELECT dot_number, created_at FROM fmcsa_carrier_safer
+WHERE created_at < ? AND (created_at > ? OR (created_at = ? AND dot_number > ?))
+ORDER BY created_at, dot_number
+LIMIT ?;
+
+-- name: ListSnapshotsByLatestUpdateTime :many
+SELECT dot_number, latest_update_time FROM fmcsa_carrier_safer
+WHERE latest_update_time < ? AND (latest_update_time > ? OR (latest_update_time = ? AND dot_number > ?))
+ORDER BY latest_update_time, dot_number
+LIMIT ?;
+
+-- name: RecordCrawlFailure :exec
+INSERT INTO crawl_failures (dot_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at)
+VALUES
+	(?, ?, ?, ?, 1, ?, ?)
+ON DUPLICATE KEY UPDATE stage = VALUES(stage), error_class = VALUES(error_class), last_error = VALUES(last_error), attempts = attempts + 1, last_attempt_at = VALUES(last_attempt_at), next_attempt_at = VALUES(next_attempt_at);
+
+-- name: ListDueCrawlFailures :many
+SELECT dot_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at FROM crawl_failures
+WHERE next_attempt_at <= ? AND attempts < ? AND dot_number > ?
+ORDER BY dot_number
+LIMIT ?;
+
+-- name: DeleteCrawlFailure :exec
+DELETE FROM crawl_failures
+WHERE dot_number = ?;
+
+-- name: RecordDocketFailure :exec
+INSERT INTO crawl_docket_failures (docket_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at)
+VALUES
+	(?, ?, ?, ?, 1, ?, ?)
+ON DUPLICATE KEY UPDATE stage = VALUES(stage), error_class = VALUES(error_class), last_error = VALUES(last_error), attempts = attempts + 1, last_attempt_at = VALUES(last_attempt_at), next_attempt_at = VALUES(next_attempt_at);
+
+-- name: ListDueDocketFailures :many
+SELECT docket_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at FROM crawl_docket_failures
+WHERE next_attempt_at <= ? AND attempts < ? AND docket_number > ?
+ORDER BY docket_number
+LIMIT ?;
+
+-- name: DeleteDocketFailure :exec
+DELETE FROM crawl_docket_failures
+WHERE docket_number = ?;
+
+-- name: GetMaxDotNumber :one
+SELECT dot_number FROM fmcsa_carrier_safer
+ORDER BY dot_number DESC
+LIMIT 1;
+
+-- name: ListEmptyDotRanges :many
+SELECT start_dot, end_dot, probed_at FROM empty_dot_ranges
+WHERE probed_at >= ?
+ORDER BY start_dot;
+
+-- name: SaveEmptyDotRange :exec
+REPLACE INTO empty_dot_ranges (start_dot, end_dot, probed_at)
+VALUES
+	(?, ?, ?);
+
+-- name: DeleteStaleEmptyDotRanges :exec
+DELETE FROM empty_dot_ranges
+WHERE start_dot > ? AND end_dot <= ? AND probed_at < ?;
+
+-- name: SaveCarrierDocket :exec
+REPLACE INTO carrier_dockets (docket_number, dot_number, updated_at)
+VALUES
+	(?, ?, ?);
+
+-- name: ListFreshDockets :many
+SELECT d.docket_number FROM carrier_dockets d
+JOIN fmcsa_carrier_safer s ON s.dot_number = d.dot_number
+WHERE d.docket_number >= ? AND d.docket_number <= ? AND s.created_at >= ?;
+
+-- name: GetSnapshotCreatedAt :one
+SELECT created_at FROM fmcsa_carrier_safer
+WHERE dot_number = ?;
+
+-- name: GetStoredDotNumber :one
+SELECT dot_number FROM fmcsa_carrier_safer
+WHERE dot_number = ?;
+
+-- name: ListSnapshots :many
+SELECT * FROM fmcsa_carrier_safer
+WHERE dot_number > ?
+ORDER BY dot_number
+LIMIT ?;
+
+-- name: CountSnapshotsByOperatingStatus :many
+SELECT operating_status, COUNT(*) AS carriers FROM fmcsa_carrier_safer
+GROUP BY operating_status
+ORDER BY carriers DESC;
+
+-- name: CountCrawlFailures :many
+SELECT stage, error_class, COUNT(*) AS failures FROM crawl_failures
+GROUP BY stage, error_class
+ORDER BY failures DESC;
+
+-- name: CountDocketFailures :many
+SELECT stage, error_class, COUNT(*) AS failures FROM crawl_docket_failures
+GROUP BY stage, error_class
+ORDER BY failures DESC;
+
+-- name: ListCrawlCheckpoints :many
+SELECT * FROM crawl_checkpoint
+ORDER BY name;
+
+-- name: CountEmptyDotRanges :one
+SELECT COUNT(*) FROM empty_dot_ranges;
+
+-- name: CreateCrawlRun :execresult
+INSERT INTO crawl_runs (mode, started_at)
+VALUES (?, ?);
+
+-- name: FinishCrawlRun :exec
+UPDATE crawl_runs
+SET ended_at = ?, first_dot = ?, last_dot = ?, found = ?, not_found = ?, failed = ?, written = ?, requests = ?, retries = ?, exit_reason = ?
+WHERE id = ?;
+
+-- name: ListRecentCrawlRuns :many
+SELECT * FROM crawl_runs
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: GetSaferSnapshot :one
+SELECT * FROM fmcsa_carrier_safer
+WHERE dot_number = ?;
+
+-- name: CloseSaferHistory :exec
+UPDATE fmcsa_carrier_safer_history
+SET valid_to = ?
+WHERE dot_number = ? AND valid_to = 0;
+
+-- name: AppendSaferHistory :exec
+INSERT INTO fmcsa_carrier_safer_history (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date, valid_from)
+SELECT c.entity_type, c.operating_status, c.oos_date, c.legal_name, c.dba_name, c.address, c.telephone, c.mailing_address, c.dot_number, c.state_carrier_id_number, c.docket_number, c.duns_number, c.power_units, c.drivers, c.mcs_150_form_date, c.mcs_150_mileage_year, c.carrier_operation, c.oc_authorized_for_hire, c.oc_private_passenger_business, c.oc_us_mail, c.oc_local_government, c.oc_exempt_for_hire, c.oc_private_passenger_non_business, c.oc_federal_government, c.oc_indian_tribe, c.oc_private_property, c.oc_migrant, c.oc_state_government, c.oc_other, c.cc_general_freight, c.cc_motor_vehicles, c.cc_building_materials, c.cc_fresh_product, c.cc_passengers, c.cc_grain_feed_hay, c.cc_garbage_refuse_trash, c.cc_commodities_dry_bulk, c.cc_paper_products, c.cc_construction, c.cc_household_goods, c.cc_drive_away_towaway, c.cc_mobile_homes, c.cc_liquids_gases, c.cc_oilfield_equipment, c.cc_coal_coke, c.cc_us_mail, c.cc_refrigerated_food, c.cc_utility, c.cc_waterwell, c.cc_metal_sheets_coils_rolls, c.cc_logs_poles_beams_lumber, c.cc_machinery_large_objects, c.cc_intermodal_containers, c.cc_livestock, c.cc_meat, c.cc_chemicals, c.cc_beverages, c.cc_farm_supplies, c.cc_other, c.us_inspection_vehicle, c.us_inspection_driver, c.us_inspection_hazmat, c.us_inspection_iep, c.us_crash_summary, c.can_inspection_vehicle, c.can_inspection_driver, c.can_crash_summary, c.safety_rating_date, c.safety_rating_review_date, c.safety_rating, c.safety_rating_type, c.latest_update_time, c.created_at, c.inactive_date, ?
+FROM fmcsa_carrier_safer c
+WHERE c.dot_number = ? AND NOT EXISTS (
+	SELECT 1 FROM fmcsa_carrier_safer_history h
+	WHERE h.dot_number = c.dot_number AND h.valid_to = 0
+);
+
+-- name: ListSaferHistory :many
+SELECT * FROM fmcsa_carrier_safer_history
+WHERE dot_number = ?
+ORDER BY valid_from, id;
+
+-- name: GetSaferHistoryAsOf :one
+SELECT * FROM fmcsa_carrier_safer_history
+WHERE dot_number = sqlc.arg(dot_number) AND valid_from <= sqlc.arg(as_of) AND (valid_to = 0 OR valid_to > sqlc.arg(as_of))
+ORDER BY valid_from DESC, id DESC
+LIMIT 1;
+
+-- name: CreateCarrierEvent :exec
+INSERT INTO carrier_events (dot_number, event_type, details, occurred_at)
+VALUES (?, ?, ?, ?);
+
+-- name: ListCarrierEvents :many
+SELECT * FROM carrier_events
+WHERE event_type = ? AND occurred_at >= ? AND occurred_at < ?
+ORDER BY occurred_at, id
+LIMIT ?;
+
+-- name: ListAllCarrierEvents :many
+SELECT * FROM carrier_events
+WHERE occurred_at >= ? AND occurred_at < ?
+ORDER BY occurred_at, id
+LIMIT ?;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (url, event_type, dot_number, attempts, status_code, last_error, delivered, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
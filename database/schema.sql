@@ -72,5 +72,177 @@ CREATE TABLE `fmcsa_carrier_safer` (
   `safety_rating_type` varchar(255) NOT NULL,
   `latest_update_time` date DEFAULT NULL,
   `created_at` int NOT NULL,
+  `inactive_date` date DEFAULT NULL,
   PRIMARY KEY (`dot_number`)
-);
\ No newline at end of file
+);
+
+CREATE TABLE `crawl_checkpoint` (
+  `name` varchar(64) NOT NULL,
+  `watermark` int NOT NULL,
+  `pending` json NOT NULL,
+  `updated_at` int NOT NULL,
+  PRIMARY KEY (`name`)
+);
+
+CREATE TABLE `crawl_failures` (
+  `dot_number` int NOT NULL,
+  `stage` varchar(16) NOT NULL,
+  `error_class` varchar(32) NOT NULL,
+  `last_error` varchar(1024) NOT NULL,
+  `attempts` int NOT NULL,
+  `last_attempt_at` int NOT NULL,
+  `next_attempt_at` int NOT NULL,
+  PRIMARY KEY (`dot_number`),
+  KEY `next_attempt_at` (`next_attempt_at`)
+);
+
+CREATE TABLE `crawl_docket_failures` (
+  `docket_number` int NOT NULL,
+  `stage` varchar(16) NOT NULL,
+  `error_class` varchar(32) NOT NULL,
+  `last_error` varchar(1024) NOT NULL,
+  `attempts` int NOT NULL,
+  `last_attempt_at` int NOT NULL,
+  `next_attempt_at` int NOT NULL,
+  PRIMARY KEY (`docket_number`),
+  KEY `next_attempt_at` (`next_attempt_at`)
+);
+
+CREATE TABLE `empty_dot_ranges` (
+  `start_dot` int NOT NULL,
+  `end_dot` int NOT NULL,
+  `probed_at` int NOT NULL,
+  PRIMARY KEY (`start_dot`)
+);
+
+CREATE TABLE `carrier_dockets` (
+  `docket_number` int NOT NULL,
+  `dot_number` int NOT NULL,
+  `updated_at` int NOT NULL,
+  PRIMARY KEY (`docket_number`),
+  KEY `dot_number` (`dot_number`)
+);
+
+CREATE TABLE `crawl_runs` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `mode` varchar(16) NOT NULL,
+  `started_at` int NOT NULL,
+  `ended_at` int NOT NULL DEFAULT 0,
+  `first_dot` int NOT NULL DEFAULT 0,
+  `last_dot` int NOT NULL DEFAULT 0,
+  `found` int NOT NULL DEFAULT 0,
+  `not_found` int NOT NULL DEFAULT 0,
+  `failed` int NOT NULL DEFAULT 0,
+  `written` int NOT NULL DEFAULT 0,
+  `requests` int NOT NULL DEFAULT 0,
+  `retries` int NOT NULL DEFAULT 0,
+  `exit_reason` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `fmcsa_carrier_safer_history` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `entity_type` varchar(255) NOT NULL,
+  `operating_status` varchar(255) NOT NULL,
+  `oos_date` date DEFAULT NULL,
+  `legal_name` varchar(255) NOT NULL,
+  `dba_name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL,
+  `address` varchar(255) NOT NULL,
+  `telephone` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL,
+  `mailing_address` varchar(255) NOT NULL,
+  `dot_number` int NOT NULL,
+  `state_carrier_id_number` varchar(255) NOT NULL,
+  `docket_number` varchar(255) NOT NULL,
+  `duns_number` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL,
+  `power_units` int NOT NULL,
+  `drivers` int NOT NULL,
+  `mcs_150_form_date` date DEFAULT NULL,
+  `mcs_150_mileage_year` varchar(255) NOT NULL,
+  `carrier_operation` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL,
+  `oc_authorized_for_hire` tinyint(1) NOT NULL,
+  `oc_private_passenger_business` tinyint(1) NOT NULL,
+  `oc_us_mail` tinyint(1) NOT NULL,
+  `oc_local_government` tinyint(1) NOT NULL,
+  `oc_exempt_for_hire` tinyint(1) NOT NULL,
+  `oc_private_passenger_non_business` tinyint(1) NOT NULL,
+  `oc_federal_government` tinyint(1) NOT NULL,
+  `oc_indian_tribe` tinyint(1) NOT NULL,
+  `oc_private_property` tinyint(1) NOT NULL,
+  `oc_migrant` tinyint(1) NOT NULL,
+  `oc_state_government` tinyint(1) NOT NULL,
+  `oc_other` varchar(255) NOT NULL,
+  `cc_general_freight` tinyint(1) NOT NULL,
+  `cc_motor_vehicles` tinyint(1) NOT NULL,
+  `cc_building_materials` tinyint(1) NOT NULL,
+  `cc_fresh_product` tinyint(1) NOT NULL,
+  `cc_passengers` tinyint(1) NOT NULL,
+  `cc_grain_feed_hay` tinyint(1) NOT NULL,
+  `cc_garbage_refuse_trash` tinyint(1) NOT NULL,
+  `cc_commodities_dry_bulk` tinyint(1) NOT NULL,
+  `cc_paper_products` tinyint(1) NOT NULL,
+  `cc_construction` tinyint(1) NOT NULL,
+  `cc_household_goods` tinyint(1) NOT NULL,
+  `cc_drive_away_towaway` tinyint(1) NOT NULL,
+  `cc_mobile_homes` tinyint(1) NOT NULL,
+  `cc_liquids_gases` tinyint(1) NOT NULL,
+  `cc_oilfield_equipment` tinyint(1) NOT NULL,
+  `cc_coal_coke` tinyint(1) NOT NULL,
+  `cc_us_mail` tinyint(1) NOT NULL,
+  `cc_refrigerated_food` tinyint(1) NOT NULL,
+  `cc_utility` tinyint(1) NOT NULL,
+  `cc_waterwell` tinyint(1) NOT NULL,
+  `cc_metal_sheets_coils_rolls` tinyint(1) NOT NULL,
+  `cc_logs_poles_beams_lumber` tinyint(1) NOT NULL,
+  `cc_machinery_large_objects` tinyint(1) NOT NULL,
+  `cc_intermodal_containers` tinyint(1) NOT NULL,
+  `cc_livestock` tinyint(1) NOT NULL,
+  `cc_meat` tinyint(1) NOT NULL,
+  `cc_chemicals` tinyint(1) NOT NULL,
+  `cc_beverages` tinyint(1) NOT NULL,
+  `cc_farm_supplies` tinyint(1) NOT NULL,
+  `cc_other` varchar(255) NOT NULL,
+  `us_inspection_vehicle` json NOT NULL,
+  `us_inspection_driver` json NOT NULL,
+  `us_inspection_hazmat` json NOT NULL,
+  `us_inspection_iep` json NOT NULL,
+  `us_crash_summary` json NOT NULL,
+  `can_inspection_vehicle` json NOT NULL,
+  `can_inspection_driver` json NOT NULL,
+  `can_crash_summary` json NOT NULL,
+  `safety_rating_date` date DEFAULT NULL,
+  `safety_rating_review_date` date DEFAULT NULL,
+  `safety_rating` varchar(255) NOT NULL,
+  `safety_rating_type` varchar(255) NOT NULL,
+  `latest_update_time` date DEFAULT NULL,
+  `created_at` int NOT NULL,
+  `inactive_date` date DEFAULT NULL,
+  `valid_from` int NOT NULL,
+  `valid_to` int NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  KEY `dot_number` (`dot_number`, `valid_from`)
+);
+
+CREATE TABLE `carrier_events` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `dot_number` int NOT NULL,
+  `event_type` varchar(32) NOT NULL,
+  `details` json NOT NULL,
+  `occurred_at` int NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `event_type` (`event_type`, `occurred_at`),
+  KEY `dot_number` (`dot_number`)
+);
+
+CREATE TABLE `webhook_deliveries` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `url` varchar(1024) NOT NULL,
+  `event_type` varchar(32) NOT NULL,
+  `dot_number` int NOT NULL,
+  `attempts` int NOT NULL,
+  `status_code` int NOT NULL,
+  `last_error` varchar(1024) NOT NULL,
+  `delivered` tinyint(1) NOT NULL,
+  `created_at` int NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `created_at` (`created_at`)
+);
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"carrierleads.com/internal/crawler"
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+)
+
+// runEvents prints the carrier events of a type, or of every type, detected in a
+// time range
+func runEvents(ctx context.Context, config Config, args []string) error {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	eventType := fs.String("type", "", fmt.Sprintf("print only the events of this type, one of %v", crawler.EventTypes))
+	since := fs.String("since", "", "print the events detected on or after this date, formatted as 2006-01-02")
+	until := fs.String("until", "", "print the events detected before this date, formatted as 2006-01-02")
+	limit := fs.Int("limit", 100, "most events printed")
+	parseFlags(fs, &config, args, 0, "events [flags]")
+	if config.DBUrl == "" {
+		return errNoDatabase
+	}
+	if *eventType != "" && !knownEventType(*eventType) {
+		return fmt.Errorf("unknown event type %q, expected one of %v", *eventType, crawler.EventTypes)
+	}
+	from, err := parseDate(*since, time.Unix(0, 0))
+	if err != nil {
+		return err
+	}
+	to, err := parseDate(*until, time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	d := dao.Instance(config.DBUrl)
+
+	var events []carrierleads.CarrierEvent
+	if *eventType != "" {
+		events, err = d.Queries.ListCarrierEvents(ctx, carrierleads.ListCarrierEventsParams{
+			EventType:    *eventType,
+			OccurredAt:   from,
+			OccurredAt_2: to,
+			Limit:        int32(*limit),
+		})
+	} else {
+		events, err = d.Queries.ListAllCarrierEvents(ctx, carrierleads.ListAllCarrierEventsParams{
+			OccurredAt:   from,
+			OccurredAt_2: to,
+			Limit:        int32(*limit),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "detected\tdot\ttype\tchanges")
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n",
+			time.Unix(int64(e.OccurredAt), 0).Format(time.RFC3339), e.DotNumber, e.EventType, e.Details)
+	}
+	return w.Flush()
+}
+
+func knownEventType(t string) bool {
+	for _, known := range crawler.EventTypes {
+		if string(known) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDate returns the unix time of a date formatted as 2006-01-02, or of def if the
+// date is empty
+func parseDate(date string, def time.Time) (int32, error) {
+	if date == "" {
+		return int32(def.Unix()), nil
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0, err
+	}
+	return int32(t.Unix()), nil
+}
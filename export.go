@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"carrierleads.com/internal/lib/safer"
+)
+
+func runExport(ctx context.Context, config Config, args []string) (err error) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "jsonl", "output format, either jsonl or csv")
+	output := fs.String("output", "-", "file written, - for stdout")
+	parseFlags(fs, &config, args, 0, "export [flags]")
+
+	if config.DBUrl == "" {
+		return errNoDatabase
+	}
+
+	sink, err := openFileSink(*format, *output)
+	if err != nil {
+		return
+	}
+	err = newCrawler(config).EachSnapshot(ctx, func(s *safer.CompanySnapshot) error {
+		return sink.Write(ctx, s)
+	})
+	if cerr := sink.Close(); err == nil {
+		err = cerr
+	}
+	return
+}
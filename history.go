@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+)
+
+// runHistory prints the versions stored for a dot number, or the one valid at a date
+func runHistory(ctx context.Context, config Config, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	asOf := fs.String("as-of", "", "print only the version valid at this date, formatted as 2006-01-02")
+	number := parseFlags(fs, &config, args, 1, "history [flags] <dot>")[0]
+	if config.DBUrl == "" {
+		return errNoDatabase
+	}
+	dotNumber, err := strconv.Atoi(number)
+	if err != nil {
+		return fmt.Errorf("invalid dot number %q", number)
+	}
+	d := dao.Instance(config.DBUrl)
+
+	var rows []carrierleads.FmcsaCarrierSaferHistory
+	if *asOf != "" {
+		date, err := time.Parse("2006-01-02", *asOf)
+		if err != nil {
+			return err
+		}
+		row, err := d.Queries.GetSaferHistoryAsOf(ctx, carrierleads.GetSaferHistoryAsOfParams{
+			DotNumber: int32(dotNumber),
+			AsOf:      int32(date.Unix()),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no version of %d at %s", dotNumber, *asOf)
+		}
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	} else {
+		if rows, err = d.Queries.ListSaferHistory(ctx, int32(dotNumber)); err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "from\tto\tstatus\tpower units\tdrivers\tsafety rating\tout of service\tlegal name")
+	unix := func(t int32) string {
+		if t == 0 {
+			return "-"
+		}
+		return time.Unix(int64(t), 0).Format(time.RFC3339)
+	}
+	date := func(t sql.NullTime) string {
+		if !t.Valid {
+			return "-"
+		}
+		return t.Time.Format("2006-01-02")
+	}
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			unix(r.ValidFrom), unix(r.ValidTo), r.OperatingStatus, r.PowerUnits, r.Drivers,
+			r.SafetyRating, date(r.OosDate), r.LegalName)
+	}
+	return w.Flush()
+}
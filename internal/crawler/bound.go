@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/lib/safer"
+)
+
+const (
+	// issued dot numbers are sparse, a probe checks this many consecutive dot numbers
+	// before calling the range empty
+	probeWidth = 25
+	// first step of the gallop past the last known dot number
+	gallopStep = 1000
+)
+
+// existsFunc reports whether a carrier exists for the dot number
+type existsFunc func(ctx context.Context, dotNumber int) (bool, error)
+
+// discoverUpperBound estimates the highest issued dot number above from. It gallops
+// upward with doubling steps until a probe finds an empty range, then bisects between
+// the last occupied and the first empty probe. The estimate is the start of the
+// first empty probe, carriers may still exist sparsely above it.
+func discoverUpperBound(ctx context.Context, from int, exists existsFunc) (int, error) {
+	// probe reports whether any dot number in [dot, dot+probeWidth) exists and
+	// returns the first one that does
+	probe := func(dot int) (int, bool, error) {
+		for d := dot; d < dot+probeWidth; d++ {
+			ok, err := exists(ctx, d)
+			if err != nil || ok {
+				return d, ok, err
+			}
+		}
+		return 0, false, nil
+	}
+
+	low, high := from, 0
+	for step := gallopStep; high == 0; step *= 2 {
+		found, ok, err := probe(low + step)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			low = found
+		} else {
+			high = low + step
+		}
+	}
+	for high-low > probeWidth {
+		mid := low + (high-low)/2
+		found, ok, err := probe(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			low = found
+		} else {
+			high = mid
+		}
+	}
+	return high, nil
+}
+
+// saferExists probes SAFER for a dot number, inactive records count as existing
+func (c *Crawler) saferExists(ctx context.Context, dotNumber int) (bool, error) {
+	_, err := c.getSaferSnapshot(ctx, dotNumber)
+	if errors.Is(err, safer.ErrCompanyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// lastKnownDot returns the highest dot number stored in the database, 0 if it is
+// empty or there is no database
+func lastKnownDot(ctx context.Context, dao dao.Dao) (int, error) {
+	if !hasDatabase(dao) {
+		return 0, nil
+	}
+	dot, err := dao.Queries.GetMaxDotNumber(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return int(dot), err
+}
+
+// highWater records the highest dot number found during a sweep. It is safe for
+// concurrent use.
+type highWater struct {
+	v atomic.Int64
+}
+
+func (h *highWater) raise(dot int) {
+	for {
+		current := h.v.Load()
+		if int64(dot) <= current || h.v.CompareAndSwap(current, int64(dot)) {
+			return
+		}
+	}
+}
+
+func (h *highWater) get() int {
+	return int(h.v.Load())
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func Test_discoverUpperBound(t *testing.T) {
+	// carriers every 10 dot numbers up to 123456
+	const last = 123456
+	exists := func(ctx context.Context, dot int) (bool, error) {
+		return dot <= last && dot%10 == 6, nil
+	}
+
+	for _, from := range []int{0, 100000, 123000} {
+		got, err := discoverUpperBound(context.Background(), from, exists)
+		if err != nil {
+			t.Fatalf("discoverUpperBound(%d) error = %v", from, err)
+		}
+		if got <= last-probeWidth || got > last+probeWidth {
+			t.Errorf("discoverUpperBound(%d) = %d, want within %d of %d", from, got, probeWidth, last)
+		}
+	}
+
+	// from past the last carrier gives an estimate above it
+	got, err := discoverUpperBound(context.Background(), 200000, exists)
+	if err != nil || got <= 200000 {
+		t.Errorf("discoverUpperBound(200000) = %d, %v, want above 200000", got, err)
+	}
+
+	failure := errors.New("boom")
+	_, err = discoverUpperBound(context.Background(), 0, func(ctx context.Context, dot int) (bool, error) {
+		return false, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("discoverUpperBound() error = %v, want %v", err, failure)
+	}
+}
+
+func Test_highWater(t *testing.T) {
+	var h highWater
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(dot int) {
+			defer wg.Done()
+			h.raise(dot)
+		}(i)
+	}
+	wg.Wait()
+	if got := h.get(); got != 100 {
+		t.Errorf("get() = %d, want 100", got)
+	}
+	h.raise(50)
+	if got := h.get(); got != 100 {
+		t.Errorf("get() after lower raise = %d, want 100", got)
+	}
+}
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+	log "github.com/sirupsen/logrus"
+)
+
+const dotSweepCheckpoint = "dot_sweep"
+
+// progress tracks dispatched and completed dot numbers so that a sweep can be
+// checkpointed and resumed. watermark is the highest dot number for which it and
+// every lower dot number have completed; failed dots count as completed for the
+// watermark but are kept in failed so that they get retried on resume.
+type progress struct {
+	mu        sync.Mutex
+	watermark int
+	completed map[int]struct{}
+	inflight  map[int]struct{}
+	failed    map[int]struct{}
+}
+
+func newProgress(watermark int) *progress {
+	return &progress{
+		watermark: watermark,
+		completed: map[int]struct{}{},
+		inflight:  map[int]struct{}{},
+		failed:    map[int]struct{}{},
+	}
+}
+
+func (p *progress) start(dot int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inflight[dot] = struct{}{}
+}
+
+func (p *progress) finish(dot int, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inflight, dot)
+	if ok {
+		delete(p.failed, dot)
+	} else {
+		p.failed[dot] = struct{}{}
+	}
+	if dot <= p.watermark {
+		return
+	}
+	p.completed[dot] = struct{}{}
+	for {
+		if _, done := p.completed[p.watermark+1]; !done {
+			break
+		}
+		delete(p.completed, p.watermark+1)
+		p.watermark++
+	}
+}
+
+// pending returns the sorted list of dot numbers that are either in flight or failed.
+func (p *progress) pending() (watermark int, pending []int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pending = make([]int, 0, len(p.inflight)+len(p.failed))
+	for dot := range p.inflight {
+		pending = append(pending, dot)
+	}
+	for dot := range p.failed {
+		pending = append(pending, dot)
+	}
+	sort.Ints(pending)
+	return p.watermark, pending
+}
+
+// loadCheckpoint returns the stored watermark and pending dot numbers, or a zero
+// watermark if no checkpoint exists.
+func loadCheckpoint(ctx context.Context, dao dao.Dao, name string) (watermark int, pending []int, err error) {
+	if !hasDatabase(dao) {
+		return 0, nil, nil
+	}
+	c, err := dao.Queries.GetCrawlCheckpoint(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(c.Pending, &pending)
+	return int(c.Watermark), pending, err
+}
+
+func saveCheckpoint(ctx context.Context, dao dao.Dao, name string, p *progress) error {
+	if !hasDatabase(dao) {
+		return nil
+	}
+	watermark, pending := p.pending()
+	b, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return dao.Queries.SaveCrawlCheckpoint(ctx, carrierleads.SaveCrawlCheckpointParams{
+		Name:      name,
+		Watermark: int32(watermark),
+		Pending:   json.RawMessage(b),
+		UpdatedAt: int32(time.Now().Unix()),
+	})
+}
+
+// checkpoint saves the checkpoint of an interrupted crawl
+func (c *Crawler) checkpoint(name string, p *progress) error {
+	if err := saveCheckpoint(context.Background(), c.Dao, name, p); err != nil {
+		return err
+	}
+	watermark, pending := p.pending()
+	log.WithFields(log.Fields{"watermark": watermark, "pending": len(pending)}).Info("checkpoint saved")
+	return nil
+}
+
+func deleteCheckpoint(ctx context.Context, dao dao.Dao, name string) error {
+	if !hasDatabase(dao) {
+		return nil
+	}
+	return dao.Queries.DeleteCrawlCheckpoint(ctx, name)
+}
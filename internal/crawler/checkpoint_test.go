@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+	"testing"
+
+	"carrierleads.com/internal/dao/daotest"
+)
+
+func TestProgress(t *testing.T) {
+	p := newProgress(10)
+	for dot := 11; dot <= 15; dot++ {
+		p.start(dot)
+	}
+	p.finish(12, true)
+	p.finish(11, true)
+	p.finish(14, false)
+
+	watermark, pending := p.pending()
+	if watermark != 12 {
+		t.Errorf("watermark = %d, want 12", watermark)
+	}
+	if want := []int{13, 14, 15}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("pending = %v, want %v", pending, want)
+	}
+
+	p.finish(13, true)
+	p.finish(15, true)
+	watermark, pending = p.pending()
+	if watermark != 15 {
+		t.Errorf("watermark = %d, want 15", watermark)
+	}
+	if want := []int{14}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("pending = %v, want %v", pending, want)
+	}
+
+	// a retried dot below the watermark clears the failure
+	p.start(14)
+	p.finish(14, true)
+	watermark, pending = p.pending()
+	if watermark != 15 || len(pending) != 0 {
+		t.Errorf("pending() = %d, %v, want 15, []", watermark, pending)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	db, d := daotest.New()
+	// crawl_checkpoint with a single row
+	var mu sync.Mutex
+	var stored []driver.Value
+	db.Handle("SaveCrawlCheckpoint", func(args []driver.Value) (*daotest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		stored = args
+		return nil, nil
+	})
+	db.Handle("GetCrawlCheckpoint", func(args []driver.Value) (*daotest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stored == nil || stored[0] != args[0] {
+			return nil, nil
+		}
+		return daotest.Value(stored...), nil
+	})
+	db.Handle("DeleteCrawlCheckpoint", func(args []driver.Value) (*daotest.Rows, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		stored = nil
+		return nil, nil
+	})
+
+	watermark, pending, err := loadCheckpoint(ctx, d, dotSweepCheckpoint)
+	if err != nil || watermark != 0 || pending != nil {
+		t.Errorf("loadCheckpoint() without checkpoint = %d, %v, %v, want 0, [], nil", watermark, pending, err)
+	}
+
+	p := newProgress(10)
+	for dot := 11; dot <= 14; dot++ {
+		p.start(dot)
+	}
+	p.finish(11, true)
+	p.finish(12, false)
+	p.finish(13, true)
+	if err := saveCheckpoint(ctx, d, dotSweepCheckpoint, p); err != nil {
+		t.Fatal(err)
+	}
+	watermark, pending, err = loadCheckpoint(ctx, d, dotSweepCheckpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{12, 14}; watermark != 13 || !reflect.DeepEqual(pending, want) {
+		t.Errorf("loadCheckpoint() = %d, %v, want 13, %v", watermark, pending, want)
+	}
+	if _, _, err = loadCheckpoint(ctx, d, docketSweepCheckpoint); err != nil {
+		t.Errorf("loadCheckpoint() of another sweep error = %v", err)
+	}
+
+	if err := deleteCheckpoint(ctx, d, dotSweepCheckpoint); err != nil {
+		t.Fatal(err)
+	}
+	if watermark, _, _ = loadCheckpoint(ctx, d, dotSweepCheckpoint); watermark != 0 {
+		t.Errorf("loadCheckpoint() after delete = %d, want 0", watermark)
+	}
+}
@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"errors"
+	"expvar"
+	"net"
+	"sync"
+	"time"
+
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// requests slower than this don't grow the concurrency
+	slowLatency = 5 * time.Second
+	// minimum time between two decreases so that a burst of failures from the same
+	// congested period only backs off once
+	decreaseCooldown = 5 * time.Second
+)
+
+// concurrencyVars exposes the current and target concurrency at /debug/vars
+var concurrencyVars = expvar.NewMap("crawler_concurrency")
+
+// ConcurrencyController limits the number of workers talking to SAFER at once. The
+// limit grows additively while requests are fast and successful and is halved
+// when SAFER times out or returns a non-200 response.
+type ConcurrencyController struct {
+	mu           sync.Mutex
+	cond         *sync.Cond
+	min          int
+	max          int
+	target       float64
+	active       int
+	lastDecrease time.Time
+}
+
+// NewConcurrencyController builds a controller starting at min workers and growing
+// up to max workers.
+func NewConcurrencyController(min, max int) *ConcurrencyController {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	c := &ConcurrencyController{
+		min:    min,
+		max:    max,
+		target: float64(min),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	c.publish()
+	return c
+}
+
+// Current returns the number of workers currently talking to SAFER
+func (c *ConcurrencyController) Current() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active
+}
+
+// Target returns the number of workers allowed to talk to SAFER
+func (c *ConcurrencyController) Target() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.target)
+}
+
+// acquire blocks until the worker is allowed to talk to SAFER
+func (c *ConcurrencyController) acquire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queueDepth.Inc()
+	for c.active >= int(c.target) {
+		c.cond.Wait()
+	}
+	queueDepth.Dec()
+	c.active++
+	c.publish()
+}
+
+func (c *ConcurrencyController) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	c.publish()
+	c.cond.Signal()
+}
+
+// observe adjusts the target from the outcome of a single request to SAFER
+func (c *ConcurrencyController) observe(latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case err != nil && isOverloaded(err):
+		if time.Since(c.lastDecrease) < decreaseCooldown {
+			return
+		}
+		c.lastDecrease = time.Now()
+		previous := int(c.target)
+		c.target /= 2
+		if c.target < float64(c.min) {
+			c.target = float64(c.min)
+		}
+		log.WithError(err).WithFields(log.Fields{"from": previous, "to": int(c.target)}).Warn("safer overloaded, reducing concurrency")
+	case err == nil || errors.Is(err, safer.ErrCompanyNotFound):
+		if latency >= slowLatency {
+			return
+		}
+		c.target += 1 / c.target
+		if c.target > float64(c.max) {
+			c.target = float64(c.max)
+		}
+		c.cond.Broadcast()
+	default:
+		return
+	}
+	c.publish()
+}
+
+func (c *ConcurrencyController) publish() {
+	active, target := new(expvar.Int), new(expvar.Int)
+	active.Set(int64(c.active))
+	target.Set(int64(c.target))
+	concurrencyVars.Set("current", active)
+	concurrencyVars.Set("target", target)
+	inflightWorkers.Set(float64(c.active))
+	concurrencyTarget.Set(float64(int(c.target)))
+}
+
+// isOverloaded reports whether err is a sign that SAFER is struggling to keep up or
+// refusing the crawler: a timeout or any non-200 response. SAFER answers unknown dot
+// numbers with a 200, so even a 4xx such as a 403 block calls for fewer workers.
+func isOverloaded(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var statusErr *safer.StatusError
+	return errors.As(err, &statusErr)
+}
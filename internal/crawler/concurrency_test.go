@@ -0,0 +1,80 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/lib/safer"
+)
+
+func TestConcurrencyController(t *testing.T) {
+	c := NewConcurrencyController(2, 8)
+	if got := c.Target(); got != 2 {
+		t.Fatalf("Target() = %d, want 2", got)
+	}
+
+	// every round of fast successful requests grows the target by about one
+	for i := 0; i < 6; i++ {
+		c.observe(time.Millisecond, nil)
+	}
+	if got := c.Target(); got != 4 {
+		t.Errorf("Target() after successes = %d, want 4", got)
+	}
+
+	// slow requests and not found don't shrink the target
+	c.observe(slowLatency, nil)
+	c.observe(time.Millisecond, safer.ErrCompanyNotFound)
+	if got := c.Target(); got != 4 {
+		t.Errorf("Target() after slow request = %d, want 4", got)
+	}
+
+	// a 403 block halves the target like a 5xx, once per cooldown
+	blocked := &safer.StatusError{StatusCode: 403, Status: "403 Forbidden"}
+	c.observe(time.Millisecond, blocked)
+	c.observe(time.Millisecond, blocked)
+	if got := c.Target(); got != 2 {
+		t.Errorf("Target() after 403 = %d, want 2", got)
+	}
+
+	for i := 0; i < 6; i++ {
+		c.observe(time.Millisecond, nil)
+	}
+	if got := c.Target(); got != 4 {
+		t.Fatalf("Target() after successes = %d, want 4", got)
+	}
+	c.lastDecrease = time.Time{}
+	overloaded := &safer.StatusError{StatusCode: 503, Status: "503 Service Unavailable"}
+	c.observe(time.Millisecond, overloaded)
+	c.observe(time.Millisecond, overloaded)
+	if got := c.Target(); got != 2 {
+		t.Errorf("Target() after overload = %d, want 2", got)
+	}
+
+	c.lastDecrease = time.Time{}
+	c.observe(time.Millisecond, overloaded)
+	if got := c.Target(); got != 2 {
+		t.Errorf("Target() below min = %d, want 2", got)
+	}
+}
+
+func TestConcurrencyController_Acquire(t *testing.T) {
+	c := NewConcurrencyController(1, 2)
+	c.acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		c.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquire() should block while the target is reached")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.release()
+	<-acquired
+	if got := c.Current(); got != 1 {
+		t.Errorf("Current() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	docketSweepCheckpoint = "docket_sweep"
+	// number of dockets whose freshness is looked up at once
+	docketPageSize = 10000
+)
+
+// CrawlDockets walks the MC/MX docket numbers from first to last, resolves each of
+// them to its carrier and records the docket to dot number association. Carriers
+// stored within maxAge are not written again, and dockets already associated with
+// such a carrier are skipped without asking SAFER. Progress is checkpointed in the
+// database and the sweep resumes from the last checkpoint unless restart is set. The
+// dockets that fail are added to the failure queue of the dockets and left to
+// DrainFailures, only those that couldn't be queued stay pending in the checkpoint.
+// An interrupted sweep saves its checkpoint before returning.
+func (c *Crawler) CrawlDockets(ctx context.Context, first, last int, maxAge time.Duration, restart bool) (err error) {
+	cc, dao := c.Concurrency, c.Dao
+	dbCtx := context.Background()
+	cutoff := int32(time.Now().Add(-maxAge).Unix())
+
+	if restart {
+		if err = deleteCheckpoint(ctx, dao, docketSweepCheckpoint); err != nil {
+			return
+		}
+	}
+	start, retry, err := loadCheckpoint(ctx, dao, docketSweepCheckpoint)
+	if err != nil {
+		return
+	}
+	if start > 0 {
+		log.WithFields(log.Fields{"watermark": start, "pending": len(retry)}).Info("resuming from checkpoint")
+	}
+	if start < first-1 {
+		start = first - 1
+	}
+	p := newProgress(start)
+
+	workers := newPool(cc.max)
+	// a docket dropped once ctx is done is left in flight, so that the checkpoint keeps
+	// it pending
+	process := func(docket int) {
+		stage, err := c.crawlDocket(ctx, dbCtx, docket, cutoff)
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+		ok := err == nil
+		if err != nil {
+			// a docket in the failure queue is left to DrainFailures
+			ok = c.recordDocketFailure(dbCtx, docket, stage, err, time.Now())
+		}
+		p.finish(docket, ok)
+	}
+
+	// retry the dockets left pending below the checkpoint, the ones above it are
+	// covered by the sweep
+	for _, docket := range retry {
+		if docket > start {
+			break
+		}
+		docketNumber := docket
+		p.start(docketNumber)
+		if c.submit(ctx, workers, func() { process(docketNumber) }) != nil {
+			break
+		}
+	}
+
+	var fresh map[int]struct{}
+	freshUpTo, skipped := start, 0
+	docket := start + 1
+	for ; docket <= last && ctx.Err() == nil; docket++ {
+		if docket > freshUpTo {
+			freshUpTo = docket + docketPageSize - 1
+			var err error
+			if fresh, err = freshDockets(ctx, dao, docket, freshUpTo, cutoff); err != nil {
+				log.WithError(err).Error("failed to list fresh dockets")
+			}
+		}
+		if _, ok := fresh[docket]; ok {
+			skipped++
+			p.start(docket)
+			p.finish(docket, true)
+			continue
+		}
+		if docket%500 == 0 {
+			log.WithFields(log.Fields{"docket": docket, "concurrency": cc.Current(), "target": cc.Target()}).Info("processing")
+			c.flush(dbCtx)
+			if err := saveCheckpoint(dbCtx, dao, docketSweepCheckpoint, p); err != nil {
+				log.WithError(err).Error("failed to save checkpoint")
+			}
+		}
+		docketNumber := docket
+		p.start(docketNumber)
+		if c.submit(ctx, workers, func() { process(docketNumber) }) != nil {
+			break
+		}
+		cursor.WithLabelValues("docket").Set(float64(docket))
+	}
+
+	err = c.finish(ctx, workers, func() error {
+		return c.checkpoint(docketSweepCheckpoint, p)
+	})
+	if err != nil {
+		return
+	}
+	log.WithFields(log.Fields{"docket": last, "skipped": skipped}).Info("last docket reached")
+
+	// the sweep is complete, the next run starts over
+	err = deleteCheckpoint(dbCtx, dao, docketSweepCheckpoint)
+	return
+}
+
+// crawlDocket resolves docket to its carrier, records the association and writes the
+// carrier unless it was stored after cutoff. It returns the stage the docket failed
+// at along with the error, or nil if it is done, including when it has no active
+// carrier. A carrier that can't be written is added to the failure queue of the dot
+// numbers instead, the docket is done.
+func (c *Crawler) crawlDocket(ctx, dbCtx context.Context, docket int, cutoff int32) (string, error) {
+	s, err := fetchWithRetry(ctx, c.Concurrency, strconv.Itoa(docket), c.Client.GetCompanyByMCMXContext)
+	tally.lookup(0, err)
+	// inactive dockets don't tell their dot number
+	if errors.Is(err, safer.ErrCompanyNotFound) || errors.Is(err, safer.ErrRecordInactive) {
+		return "", nil
+	}
+	if err != nil {
+		return fetchStage(err), err
+	}
+	dotNumber, err := strconv.Atoi(s.DOTNumber)
+	if err != nil {
+		return stageParse, fmt.Errorf("invalid dot number %q: %w", s.DOTNumber, err)
+	}
+
+	err = c.Dao.Queries.SaveCarrierDocket(dbCtx, carrierleads.SaveCarrierDocketParams{
+		DocketNumber: int32(docket),
+		DotNumber:    int32(dotNumber),
+		UpdatedAt:    int32(time.Now().Unix()),
+	})
+	if err != nil {
+		return stageWrite, err
+	}
+	if createdAt, err := c.Dao.Queries.GetSnapshotCreatedAt(dbCtx, int32(dotNumber)); err == nil && createdAt >= cutoff {
+		return "", nil
+	}
+	c.write(dbCtx, dotNumber, s, func(err error) {
+		if err != nil {
+			c.recordFailure(dbCtx, dotNumber, stageWrite, err, time.Now())
+		}
+	})
+	return "", nil
+}
+
+// freshDockets returns the dockets between first and last associated with a carrier
+// stored after cutoff
+func freshDockets(ctx context.Context, dao dao.Dao, first, last int, cutoff int32) (map[int]struct{}, error) {
+	dockets, err := dao.Queries.ListFreshDockets(ctx, carrierleads.ListFreshDocketsParams{
+		DocketNumber:   int32(first),
+		DocketNumber_2: int32(last),
+		CreatedAt:      cutoff,
+	})
+	fresh := make(map[int]struct{}, len(dockets))
+	for _, docket := range dockets {
+		fresh[int(docket)] = struct{}{}
+	}
+	return fresh, err
+}
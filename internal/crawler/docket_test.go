@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao/daotest"
+)
+
+func TestCrawler_CrawlDockets_resume(t *testing.T) {
+	f := newFakeSAFER()
+	for docket := 1; docket <= 10; docket++ {
+		f.dockets[docket] = 100 + docket
+		f.carrier(100 + docket)
+	}
+	f.statuses[docketKey(3)] = http.StatusForbidden
+	f.blocked[docketKey(7)] = true
+	db, d := daotest.New()
+	c, sink, stop := testCrawler(f, d)
+	defer stop()
+	// one worker, so that docket 3 has failed once docket 7 is in flight
+	c.Concurrency = NewConcurrencyController(1, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-f.started
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		close(f.unblock)
+	}()
+	if err := c.CrawlDockets(ctx, 1, 10, time.Hour, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CrawlDockets() error = %v, want %v", err, context.Canceled)
+	}
+	failures := db.Calls("RecordDocketFailure")
+	if len(failures) != 1 || daotest.Int(failures[0][0]) != 3 {
+		t.Fatalf("queued failures %v, want docket 3", failures)
+	}
+	watermark, pending := savedCheckpoint(t, db)
+	if watermark < 3 || len(pending) > 0 && pending[0] <= 3 {
+		t.Fatalf("checkpoint at %d pending %v, want docket 3 done", watermark, pending)
+	}
+
+	// the failed docket is left to the failure queue, the rest of the sweep resumes
+	delete(f.statuses, docketKey(3))
+	delete(f.blocked, docketKey(7))
+	storedCheckpoint(t, db, docketSweepCheckpoint, watermark, pending)
+	if err := c.CrawlDockets(context.Background(), 1, 10, time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+	for docket := 1; docket <= 10; docket++ {
+		if n := f.count(docketKey(docket)); n != 1 {
+			t.Errorf("docket %d looked up %d times, want once", docket, n)
+		}
+	}
+	if got := len(sink.dots()); got != 9 {
+		t.Errorf("wrote %v, want every carrier but the one of docket 3", sink.dots())
+	}
+	if len(db.Calls("DeleteCrawlCheckpoint")) != 1 {
+		t.Error("the completed sweep didn't delete its checkpoint")
+	}
+}
@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+)
+
+// dotRange is an inclusive range of dot numbers
+type dotRange struct {
+	start, end int
+}
+
+// emptyRanges collects the dot numbers SAFER has no carrier for into ranges of
+// consecutive dot numbers. Dot numbers can be added in any order. It is safe for
+// concurrent use.
+type emptyRanges struct {
+	mu     sync.Mutex
+	starts map[int]int // start -> end
+	ends   map[int]int // end -> start
+}
+
+func newEmptyRanges() *emptyRanges {
+	return &emptyRanges{
+		starts: map[int]int{},
+		ends:   map[int]int{},
+	}
+}
+
+func (r *emptyRanges) add(dot int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start, end := dot, dot
+	if s, ok := r.ends[dot-1]; ok {
+		delete(r.ends, dot-1)
+		start = s
+	}
+	if e, ok := r.starts[dot+1]; ok {
+		delete(r.starts, dot+1)
+		end = e
+	}
+	r.starts[start] = end
+	r.ends[end] = start
+}
+
+// take removes and returns the sorted ranges ending below dot
+func (r *emptyRanges) take(below int) []dotRange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ranges []dotRange
+	for start, end := range r.starts {
+		if end < below {
+			ranges = append(ranges, dotRange{start, end})
+			delete(r.starts, start)
+			delete(r.ends, end)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	return ranges
+}
+
+// rangesBelow returns the sorted ranges that end below dot
+func rangesBelow(ranges []dotRange, dot int) []dotRange {
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].end >= dot })
+	return ranges[:i]
+}
+
+// loadEmptyRanges returns the sorted ranges known to be empty that were probed after
+// cutoff
+func loadEmptyRanges(ctx context.Context, dao dao.Dao, cutoff time.Time) ([]dotRange, error) {
+	if !hasDatabase(dao) {
+		return nil, nil
+	}
+	rows, err := dao.Queries.ListEmptyDotRanges(ctx, int32(cutoff.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]dotRange, len(rows))
+	for i, row := range rows {
+		ranges[i] = dotRange{int(row.StartDot), int(row.EndDot)}
+	}
+	return ranges, nil
+}
+
+// saveEmptyRanges stores the ranges found empty by a sweep that started after
+// sweepStart and has completed every dot number up to watermark. The ranges probed
+// before cutoff that the sweep went through are replaced.
+func saveEmptyRanges(ctx context.Context, dao dao.Dao, ranges []dotRange, sweepStart, watermark int, cutoff time.Time) error {
+	if !hasDatabase(dao) {
+		return nil
+	}
+	err := dao.Queries.DeleteStaleEmptyDotRanges(ctx, carrierleads.DeleteStaleEmptyDotRangesParams{
+		StartDot: int32(sweepStart),
+		EndDot:   int32(watermark),
+		ProbedAt: int32(cutoff.Unix()),
+	})
+	if err != nil {
+		return err
+	}
+	now := int32(time.Now().Unix())
+	for _, r := range ranges {
+		err = dao.Queries.SaveEmptyDotRange(ctx, carrierleads.SaveEmptyDotRangeParams{
+			StartDot: int32(r.start),
+			EndDot:   int32(r.end),
+			ProbedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmptyRanges(t *testing.T) {
+	r := newEmptyRanges()
+	for _, dot := range []int{5, 3, 4, 10, 1, 12, 11, 20} {
+		r.add(dot)
+	}
+
+	if got, want := r.take(12), []dotRange{{1, 1}, {3, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take(12) = %v, want %v", got, want)
+	}
+	// ranges that reach the limit are kept, they may still grow
+	r.add(9)
+	if got, want := r.take(13), []dotRange{{9, 12}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take(13) = %v, want %v", got, want)
+	}
+	if got, want := r.take(100), []dotRange{{20, 20}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take(100) = %v, want %v", got, want)
+	}
+	if got := r.take(100); len(got) != 0 {
+		t.Errorf("take() after everything was taken = %v", got)
+	}
+}
+
+func Test_rangesBelow(t *testing.T) {
+	ranges := []dotRange{{1, 1}, {3, 5}, {9, 12}}
+	tests := []struct {
+		dot  int
+		want []dotRange
+	}{
+		{dot: 1, want: []dotRange{}},
+		{dot: 5, want: []dotRange{{1, 1}}},
+		{dot: 6, want: []dotRange{{1, 1}, {3, 5}}},
+		{dot: 100, want: ranges},
+	}
+	for _, tt := range tests {
+		if got := rangesBelow(ranges, tt.dot); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rangesBelow(%d) = %v, want %v", tt.dot, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"strconv"
+	"strings"
+
+	"carrierleads.com/internal/lib/safer"
+)
+
+// EventType is a kind of change in the life of a carrier
+type EventType string
+
+const (
+	// EventNewCarrier is a dot number stored for the first time
+	EventNewCarrier EventType = "new_carrier"
+	// EventOutOfService is a carrier placed out of service
+	EventOutOfService EventType = "out_of_service"
+	// EventAuthorityAdded is a carrier granted a new MC, MX or FF number
+	EventAuthorityAdded EventType = "authority_added"
+	// EventRatingDowngrade is a carrier whose safety rating got worse
+	EventRatingDowngrade EventType = "rating_downgrade"
+	// EventFleetDoubled is a carrier with at least twice the power units it had
+	EventFleetDoubled EventType = "fleet_doubled"
+)
+
+// EventTypes are all the event types, in the order they are detected
+var EventTypes = []EventType{
+	EventNewCarrier, EventOutOfService, EventAuthorityAdded, EventRatingDowngrade, EventFleetDoubled,
+}
+
+// Event is a change in the life of a carrier detected by comparing its snapshot with
+// the one stored
+type Event struct {
+	Type      EventType `json:"type"`
+	DOTNumber int       `json:"dot_number"`
+	// Changes are the changes of the fields the event is about
+	Changes []safer.Change `json:"changes"`
+}
+
+// safety ratings from the best to the worst, a carrier without a rating isn't ranked
+var ratingRanks = map[string]int{
+	"SATISFACTORY":   1,
+	"CONDITIONAL":    2,
+	"UNSATISFACTORY": 3,
+}
+
+// carrierEvents returns the events of a carrier going from old to new, old is nil
+// for a carrier that wasn't stored
+func carrierEvents(old, new *safer.CompanySnapshot) []Event {
+	changes := safer.DiffSnapshots(old, new, safer.IgnoreVolatile())
+	dot, _ := strconv.Atoi(new.DOTNumber)
+	var events []Event
+	add := func(t EventType, fields ...string) {
+		e := Event{Type: t, DOTNumber: dot}
+		for _, c := range changes {
+			for _, f := range fields {
+				if c.Field == f {
+					e.Changes = append(e.Changes, c)
+				}
+			}
+		}
+		events = append(events, e)
+	}
+
+	if old == nil {
+		add(EventNewCarrier, "legal_name", "operating_status", "power_units", "mc_mx_ff_numbers")
+		return events
+	}
+	if outOfService(new) && (!outOfService(old) || laterOutOfService(old, new)) {
+		add(EventOutOfService, "operating_status", "out_of_service_date")
+	}
+	for _, c := range changes {
+		if c.Field == "mc_mx_ff_numbers" && len(c.Added) > 0 {
+			add(EventAuthorityAdded, "mc_mx_ff_numbers")
+		}
+	}
+	oldRank, newRank := ratingRanks[strings.ToUpper(old.Safety.Rating)], ratingRanks[strings.ToUpper(new.Safety.Rating)]
+	if oldRank > 0 && newRank > oldRank {
+		add(EventRatingDowngrade, "safety.rating", "safety.rating_date")
+	}
+	if old.PowerUnits > 0 && new.PowerUnits >= 2*old.PowerUnits {
+		add(EventFleetDoubled, "power_units")
+	}
+	return events
+}
+
+func outOfService(s *safer.CompanySnapshot) bool {
+	return s.OutOfServiceDate != nil || strings.Contains(strings.ToUpper(s.OperatingStatus), "OUT-OF-SERVICE")
+}
+
+// laterOutOfService tells whether new has an out of service order after the one of old
+func laterOutOfService(old, new *safer.CompanySnapshot) bool {
+	return old.OutOfServiceDate != nil && new.OutOfServiceDate != nil && new.OutOfServiceDate.After(*old.OutOfServiceDate)
+}
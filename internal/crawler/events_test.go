@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/lib/safer"
+)
+
+func Test_carrierEvents(t *testing.T) {
+	date := time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC)
+	later := date.AddDate(0, 3, 0)
+	stored := &safer.CompanySnapshot{
+		DOTNumber:        "123",
+		LegalName:        "ACME TRUCKING LLC",
+		OperatingStatus:  "AUTHORIZED FOR Property",
+		MCMXFFNumbers:    []string{"MC-456"},
+		Safety:           safer.SafetyRating{Rating: "Satisfactory"},
+		LatestUpdateDate: &date,
+		PowerUnits:       3,
+	}
+
+	tests := []struct {
+		name   string
+		old    *safer.CompanySnapshot
+		modify func(s *safer.CompanySnapshot)
+		want   []EventType
+	}{
+		{name: "new carrier", modify: func(s *safer.CompanySnapshot) {}, want: []EventType{EventNewCarrier}},
+		{name: "same", old: stored, modify: func(s *safer.CompanySnapshot) {}},
+		{name: "updated by SAFER", old: stored, modify: func(s *safer.CompanySnapshot) { s.LatestUpdateDate = &later }},
+		{name: "out of service status", old: stored, modify: func(s *safer.CompanySnapshot) { s.OperatingStatus = "OUT-OF-SERVICE" }, want: []EventType{EventOutOfService}},
+		{name: "out of service date", old: stored, modify: func(s *safer.CompanySnapshot) { s.OutOfServiceDate = &later }, want: []EventType{EventOutOfService}},
+		{name: "authority added", old: stored, modify: func(s *safer.CompanySnapshot) { s.MCMXFFNumbers = []string{"MC-456", "FF-789"} }, want: []EventType{EventAuthorityAdded}},
+		{name: "authority removed", old: stored, modify: func(s *safer.CompanySnapshot) { s.MCMXFFNumbers = nil }},
+		{name: "rating downgrade", old: stored, modify: func(s *safer.CompanySnapshot) { s.Safety.Rating = "Conditional" }, want: []EventType{EventRatingDowngrade}},
+		{name: "rating removed", old: stored, modify: func(s *safer.CompanySnapshot) { s.Safety.Rating = "" }},
+		{name: "fleet grew", old: stored, modify: func(s *safer.CompanySnapshot) { s.PowerUnits = 5 }},
+		{name: "fleet doubled", old: stored, modify: func(s *safer.CompanySnapshot) { s.PowerUnits = 6 }, want: []EventType{EventFleetDoubled}},
+		{
+			name: "several",
+			old:  stored,
+			modify: func(s *safer.CompanySnapshot) {
+				s.OutOfServiceDate = &later
+				s.Safety.Rating = "Unsatisfactory"
+			},
+			want: []EventType{EventOutOfService, EventRatingDowngrade},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := *stored
+			tt.modify(&s)
+			var got []EventType
+			for _, e := range carrierEvents(tt.old, &s) {
+				if e.DOTNumber != 123 {
+					t.Errorf("carrierEvents() dot number = %d, want 123", e.DOTNumber)
+				}
+				got = append(got, e.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("carrierEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_carrierEvents_changes(t *testing.T) {
+	old := &safer.CompanySnapshot{DOTNumber: "123", PowerUnits: 2, Drivers: 2}
+	new := &safer.CompanySnapshot{DOTNumber: "123", PowerUnits: 4, Drivers: 5}
+	got := carrierEvents(old, new)
+	want := []Event{{
+		Type:      EventFleetDoubled,
+		DOTNumber: 123,
+		Changes:   []safer.Change{{Field: "power_units", Old: 2, New: 4}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("carrierEvents() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package crawler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+)
+
+const exportPageSize = 1000
+
+// EachSnapshot calls fn with every carrier stored in the database, in dot number
+// order, until fn returns an error.
+func (c *Crawler) EachSnapshot(ctx context.Context, fn func(*safer.CompanySnapshot) error) error {
+	var lastDot int32
+	for {
+		rows, err := c.Dao.Queries.ListSnapshots(ctx, carrierleads.ListSnapshotsParams{
+			DotNumber: lastDot,
+			Limit:     exportPageSize,
+		})
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		for _, row := range rows {
+			if err = fn(SnapshotFromRow(row)); err != nil {
+				return err
+			}
+		}
+		lastDot = rows[len(rows)-1].DotNumber
+	}
+}
+
+// SnapshotFromRow converts a stored carrier back to the snapshot it was built from.
+// The MCS-150 mileage isn't stored and is always 0, and only the last unknown
+// operation classification and cargo carried are kept.
+func SnapshotFromRow(row carrierleads.FmcsaCarrierSafer) *safer.CompanySnapshot {
+	fromNullTime := func(in sql.NullTime) *time.Time {
+		if !in.Valid {
+			return nil
+		}
+		t := in.Time
+		return &t
+	}
+
+	fromInspectionSummary := func(in json.RawMessage) (ret safer.InspectionSummary) {
+		var fields []float64
+		if json.Unmarshal(in, &fields) == nil && len(fields) == 4 {
+			ret = safer.InspectionSummary{
+				Inspections:     int(fields[0]),
+				OutOfService:    int(fields[1]),
+				OutOfServicePct: float32(fields[2]),
+				NationalAverage: float32(fields[3]),
+			}
+		}
+		return
+	}
+
+	fromCrashSummary := func(in json.RawMessage) (ret safer.CrashSummary) {
+		var fields []int
+		if json.Unmarshal(in, &fields) == nil && len(fields) == 4 {
+			ret = safer.CrashSummary{Fatal: fields[0], Injury: fields[1], Tow: fields[2], Total: fields[3]}
+		}
+		return
+	}
+
+	split := func(in string) []string {
+		if in == "" {
+			return nil
+		}
+		return strings.Split(in, ",")
+	}
+
+	s := &safer.CompanySnapshot{
+		EntityType:               row.EntityType,
+		OperatingStatus:          row.OperatingStatus,
+		OutOfServiceDate:         fromNullTime(row.OosDate),
+		LegalName:                row.LegalName,
+		DBAName:                  row.DbaName,
+		PhysicalAddress:          row.Address,
+		Phone:                    row.Telephone,
+		MailingAddress:           row.MailingAddress,
+		DOTNumber:                strconv.Itoa(int(row.DotNumber)),
+		StateCarrierID:           row.StateCarrierIDNumber,
+		MCMXFFNumbers:            split(row.DocketNumber),
+		DUNSNumber:               row.DunsNumber,
+		PowerUnits:               int(row.PowerUnits),
+		Drivers:                  int(row.Drivers),
+		MCS150FormDate:           fromNullTime(row.Mcs150FormDate),
+		MCS150Year:               row.Mcs150MileageYear,
+		CarrierOperation:         split(row.CarrierOperation),
+		USVehicleInspections:     fromInspectionSummary(row.UsInspectionVehicle),
+		USDriverInspections:      fromInspectionSummary(row.UsInspectionDriver),
+		USHazmatInspections:      fromInspectionSummary(row.UsInspectionHazmat),
+		USIEPInspections:         fromInspectionSummary(row.UsInspectionIep),
+		USCrashes:                fromCrashSummary(row.UsCrashSummary),
+		CanadaVehicleInspections: fromInspectionSummary(row.CanInspectionVehicle),
+		CanadaDriverInspections:  fromInspectionSummary(row.CanInspectionDriver),
+		CanadaCrashes:            fromCrashSummary(row.CanCrashSummary),
+		Safety: safer.SafetyRating{
+			RatingDate: fromNullTime(row.SafetyRatingDate),
+			ReviewDate: fromNullTime(row.SafetyRatingReviewDate),
+			Rating:     row.SafetyRating,
+			Type:       row.SafetyRatingType,
+		},
+		LatestUpdateDate: fromNullTime(row.LatestUpdateTime),
+		InactiveDate:     fromNullTime(row.InactiveDate),
+	}
+
+	for _, oc := range []struct {
+		set   bool
+		label string
+	}{
+		{row.OcAuthorizedForHire, "Auth. For Hire"},
+		{row.OcPrivatePassengerNonBusiness, "Priv. Pass.(Non-business)"},
+		{row.OcStateGovernment, "State Gov't"},
+		{row.OcExemptForHire, "Exempt For Hire"},
+		{row.OcMigrant, "Migrant"},
+		{row.OcLocalGovernment, "Local Gov't"},
+		{row.OcPrivateProperty, "Private(Property)"},
+		{row.OcUsMail, "U.S. Mail"},
+		{row.OcIndianTribe, "Indian Nation"},
+		{row.OcPrivatePassengerBusiness, "Priv. Pass. (Business)"},
+		{row.OcFederalGovernment, "Fed. Gov't"},
+		{row.OcOther != "", row.OcOther},
+	} {
+		if oc.set {
+			s.OperationClassification = append(s.OperationClassification, oc.label)
+		}
+	}
+	for _, cc := range []struct {
+		set   bool
+		label string
+	}{
+		{row.CcGeneralFreight, "General Freight"},
+		{row.CcLiquidsGases, "Liquids/Gases"},
+		{row.CcChemicals, "Chemicals"},
+		{row.CcHouseholdGoods, "Household Goods"},
+		{row.CcIntermodalContainers, "Intermodal Cont."},
+		{row.CcCommoditiesDryBulk, "Commodities Dry Bulk"},
+		{row.CcMetalSheetsCoilsRolls, "Metal: sheets, coils, rolls"},
+		{row.CcPassengers, "Passengers"},
+		{row.CcRefrigeratedFood, "Refrigerated Food"},
+		{row.CcMotorVehicles, "Motor Vehicles"},
+		{row.CcOilfieldEquipment, "Oilfield Equipment"},
+		{row.CcBeverages, "Beverages"},
+		{row.CcDriveAwayTowaway, "Drive/Tow away"},
+		{row.CcLivestock, "Livestock"},
+		{row.CcPaperProducts, "Paper Products"},
+		{row.CcLogsPolesBeamsLumber, "Logs, Poles, Beams, Lumber"},
+		{row.CcGrainFeedHay, "Grain, Feed, Hay"},
+		{row.CcUtility, "Utilities"},
+		{row.CcBuildingMaterials, "Building Materials"},
+		{row.CcCoalCoke, "Coal/Coke"},
+		{row.CcFarmSupplies, "Agricultural/Farm Supplies"},
+		{row.CcMobileHomes, "Mobile Homes"},
+		{row.CcMeat, "Meat"},
+		{row.CcConstruction, "Construction"},
+		{row.CcMachineryLargeObjects, "Machinery, Large Objects"},
+		{row.CcGarbageRefuseTrash, "Garbage/Refuse"},
+		{row.CcWaterwell, "Water Well"},
+		{row.CcFreshProduct, "Fresh Produce"},
+		{row.CcUsMail, "US Mail"},
+		{row.CcOther != "", row.CcOther},
+	} {
+		if cc.set {
+			s.CargoCarried = append(s.CargoCarried, cc.label)
+		}
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+)
+
+func TestSnapshotFromRow(t *testing.T) {
+	date := time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC)
+	want := &safer.CompanySnapshot{
+		USVehicleInspections:    safer.InspectionSummary{Inspections: 4, OutOfService: 1, OutOfServicePct: 25, NationalAverage: 20.7},
+		USCrashes:               safer.CrashSummary{Injury: 1, Total: 1},
+		Safety:                  safer.SafetyRating{RatingDate: &date, Rating: "Satisfactory", Type: "Non-Ratable"},
+		LatestUpdateDate:        &date,
+		OperationClassification: []string{"Auth. For Hire", "Tribal"},
+		CarrierOperation:        []string{"Interstate"},
+		CargoCarried:            []string{"General Freight", "Metal: sheets, coils, rolls", "Grain, Feed, Hay"},
+		LegalName:               "ACME TRUCKING LLC",
+		EntityType:              "CARRIER",
+		PhysicalAddress:         "1 MAIN ST",
+		DOTNumber:               "123",
+		MCMXFFNumbers:           []string{"MC-1", "MC-2"},
+		MCS150Year:              "2021",
+		OperatingStatus:         "AUTHORIZED FOR Property",
+		PowerUnits:              3,
+		Drivers:                 2,
+	}
+	params, err := snapshotParams(want)
+	if err != nil {
+		t.Fatalf("snapshotParams() error = %v", err)
+	}
+	row := carrierleads.FmcsaCarrierSafer(params)
+	if got := SnapshotFromRow(row); !reflect.DeepEqual(got, want) {
+		t.Errorf("SnapshotFromRow() = %+v, want %+v", got, want)
+	}
+
+	if _, err := snapshotParams(&safer.CompanySnapshot{DOTNumber: "n/a"}); err == nil {
+		t.Error("snapshotParams() with an invalid dot number should fail")
+	}
+}
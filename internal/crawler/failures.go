@@ -0,0 +1,249 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+// stages of the crawl a dot number can fail at
+const (
+	stageFetch = "fetch"
+	stageParse = "parse"
+	stageWrite = "write"
+)
+
+const (
+	// failed dot numbers are retried after failureBackoffBase, doubling with every
+	// failed attempt up to failureBackoffMax
+	failureBackoffBase = time.Hour
+	failureBackoffMax  = 7 * 24 * time.Hour
+	// dot numbers that failed this many times are left in the queue for inspection
+	// but no longer retried
+	maxFailureAttempts = 10
+	failurePageSize    = 1000
+	// longest error message kept, the column is a varchar(1024)
+	maxFailureMessage = 1024
+)
+
+// fetchStage tells whether a snapshot that couldn't be fetched failed on the
+// request or on the page returned by SAFER
+func fetchStage(err error) string {
+	var parseErr *safer.ParseError
+	if errors.As(err, &parseErr) {
+		return stageParse
+	}
+	return stageFetch
+}
+
+// errorClass sums up err so that failures can be grouped without parsing the message
+func errorClass(err error) string {
+	var statusErr *safer.StatusError
+	var parseErr *safer.ParseError
+	var transportErr *safer.TransportError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		return "http_" + strconv.Itoa(statusErr.StatusCode)
+	case errors.As(err, &parseErr):
+		return "parse"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &transportErr):
+		return "transport"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "other"
+	}
+}
+
+// failureBackoff returns how long to wait before retrying a dot number whose
+// attempts-th retry failed
+func failureBackoff(attempts int) time.Duration {
+	backoff := failureBackoffBase
+	for i := 1; i < attempts && backoff < failureBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > failureBackoffMax {
+		backoff = failureBackoffMax
+	}
+	return backoff
+}
+
+// recordFailure logs the failure and adds dotNumber to the dead-letter queue, or bumps
+// its attempts if it is already there. The dot number is retried by DrainFailures
+// once next has passed.
+func (c *Crawler) recordFailure(ctx context.Context, dotNumber int, stage string, failure error, next time.Time) {
+	entry := log.WithField("dot", dotNumber).WithFields(failureFields(stage, failure))
+	entry.Warn("crawl failed")
+	tally.failure()
+	if !hasDatabase(c.Dao) {
+		return
+	}
+	err := c.Dao.Queries.RecordCrawlFailure(ctx, carrierleads.RecordCrawlFailureParams{
+		DotNumber:     int32(dotNumber),
+		Stage:         stage,
+		ErrorClass:    errorClass(failure),
+		LastError:     failureMessage(failure),
+		LastAttemptAt: int32(time.Now().Unix()),
+		NextAttemptAt: int32(next.Unix()),
+	})
+	if err != nil {
+		entry.WithField("record_error", err).Error("failed to record failure")
+	}
+}
+
+// recordDocketFailure is recordFailure for a docket number, it goes to the failure
+// queue of the dockets. It reports whether the docket was queued.
+func (c *Crawler) recordDocketFailure(ctx context.Context, docket int, stage string, failure error, next time.Time) bool {
+	entry := log.WithField("docket", docket).WithFields(failureFields(stage, failure))
+	entry.Warn("crawl failed")
+	tally.failure()
+	if !hasDatabase(c.Dao) {
+		return false
+	}
+	err := c.Dao.Queries.RecordDocketFailure(ctx, carrierleads.RecordDocketFailureParams{
+		DocketNumber:  int32(docket),
+		Stage:         stage,
+		ErrorClass:    errorClass(failure),
+		LastError:     failureMessage(failure),
+		LastAttemptAt: int32(time.Now().Unix()),
+		NextAttemptAt: int32(next.Unix()),
+	})
+	if err != nil {
+		entry.WithField("record_error", err).Error("failed to record failure")
+		return false
+	}
+	return true
+}
+
+// deleteFailure removes dotNumber from the dead-letter queue
+func (c *Crawler) deleteFailure(ctx context.Context, dotNumber int) {
+	if err := c.Dao.Queries.DeleteCrawlFailure(ctx, int32(dotNumber)); err != nil {
+		log.WithField("dot", dotNumber).WithError(err).Error("failed to remove from the failure queue")
+	}
+}
+
+// failureMessage returns the message of err, truncated to fit the failure queues
+func failureMessage(err error) string {
+	message := err.Error()
+	if len(message) > maxFailureMessage {
+		message = message[:maxFailureMessage]
+	}
+	return message
+}
+
+// DrainFailures retries the dot numbers in the dead-letter queue that are due, then
+// the dockets in theirs. Dot numbers that are fetched and stored, or turn out not to
+// exist, leave the queue, and so do the dockets resolved.
+// The others are rescheduled with an exponential backoff until they have failed
+// maxFailureAttempts times.
+func (c *Crawler) DrainFailures(ctx context.Context) (err error) {
+	cc, dao := c.Concurrency, c.Dao
+	dbCtx := context.Background()
+	now := int32(time.Now().Unix())
+
+	workers := newPool(cc.max)
+	var lastDot int32
+	retried := 0
+	for ctx.Err() == nil {
+		var rows []carrierleads.CrawlFailure
+		rows, err = dao.Queries.ListDueCrawlFailures(ctx, carrierleads.ListDueCrawlFailuresParams{
+			NextAttemptAt: now,
+			Attempts:      maxFailureAttempts,
+			DotNumber:     lastDot,
+			Limit:         failurePageSize,
+		})
+		if err != nil || len(rows) == 0 {
+			break
+		}
+		lastDot = rows[len(rows)-1].DotNumber
+		for _, row := range rows {
+			dotNumber, attempts := int(row.DotNumber), int(row.Attempts)
+			err = c.submit(ctx, workers, func() {
+				s, err := c.getSaferSnapshot(ctx, dotNumber)
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+				next := time.Now().Add(failureBackoff(attempts))
+				switch {
+				case errors.Is(err, safer.ErrCompanyNotFound):
+					c.deleteFailure(dbCtx, dotNumber)
+				case err != nil:
+					c.recordFailure(dbCtx, dotNumber, fetchStage(err), err, next)
+				default:
+					// the dot number leaves the queue once it is stored, not queued
+					c.write(dbCtx, dotNumber, s, func(err error) {
+						if err != nil {
+							c.recordFailure(dbCtx, dotNumber, stageWrite, err, next)
+							return
+						}
+						c.deleteFailure(dbCtx, dotNumber)
+					})
+				}
+			})
+			if err != nil {
+				break
+			}
+			retried++
+			if retried%500 == 0 {
+				log.WithFields(log.Fields{"retried": retried, "concurrency": cc.Current(), "target": cc.Target()}).Info("draining failures")
+			}
+		}
+	}
+
+	c.flush(dbCtx)
+
+	var lastDocket int32
+	for ctx.Err() == nil && err == nil {
+		var rows []carrierleads.CrawlDocketFailure
+		rows, err = dao.Queries.ListDueDocketFailures(ctx, carrierleads.ListDueDocketFailuresParams{
+			NextAttemptAt: now,
+			Attempts:      maxFailureAttempts,
+			DocketNumber:  lastDocket,
+			Limit:         failurePageSize,
+		})
+		if err != nil || len(rows) == 0 {
+			break
+		}
+		lastDocket = rows[len(rows)-1].DocketNumber
+		for _, row := range rows {
+			docket, attempts := int(row.DocketNumber), int(row.Attempts)
+			err = c.submit(ctx, workers, func() {
+				// every carrier found is written, however recently it was stored
+				stage, err := c.crawlDocket(ctx, dbCtx, docket, now)
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					c.recordDocketFailure(dbCtx, docket, stage, err, time.Now().Add(failureBackoff(attempts)))
+					return
+				}
+				if err := dao.Queries.DeleteDocketFailure(dbCtx, int32(docket)); err != nil {
+					log.WithField("docket", docket).WithError(err).Error("failed to remove from the failure queue")
+				}
+			})
+			if err != nil {
+				break
+			}
+			retried++
+			if retried%500 == 0 {
+				log.WithFields(log.Fields{"retried": retried, "concurrency": cc.Current(), "target": cc.Target()}).Info("draining failures")
+			}
+		}
+	}
+
+	if err := c.finish(ctx, workers, nil); err != nil {
+		log.WithField("retried", retried).Info("drain interrupted")
+		return err
+	}
+	log.WithField("retried", retried).Info("drain finished")
+	return
+}
@@ -0,0 +1,172 @@
+package crawler
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/dao/daotest"
+	"carrierleads.com/internal/lib/safer"
+)
+
+func Test_errorClass(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantClass string
+		wantStage string
+	}{
+		{name: "status", err: &safer.StatusError{StatusCode: 503}, wantClass: "http_503", wantStage: stageFetch},
+		{name: "parse", err: &safer.ParseError{Err: errors.New("no dot number")}, wantClass: "parse", wantStage: stageParse},
+		{name: "timeout", err: &safer.TransportError{Err: context.DeadlineExceeded}, wantClass: "timeout", wantStage: stageFetch},
+		{name: "transport", err: &safer.TransportError{Err: errors.New("connection reset")}, wantClass: "transport", wantStage: stageFetch},
+		{name: "other", err: errors.New("boom"), wantClass: "other", wantStage: stageFetch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err); got != tt.wantClass {
+				t.Errorf("errorClass() = %q, want %q", got, tt.wantClass)
+			}
+			if got := fetchStage(tt.err); got != tt.wantStage {
+				t.Errorf("fetchStage() = %q, want %q", got, tt.wantStage)
+			}
+		})
+	}
+}
+
+func Test_failureBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Hour},
+		{attempts: 2, want: 2 * time.Hour},
+		{attempts: 4, want: 8 * time.Hour},
+		{attempts: 9, want: failureBackoffMax},
+		{attempts: 100, want: failureBackoffMax},
+	}
+	for _, tt := range tests {
+		if got := failureBackoff(tt.attempts); got != tt.want {
+			t.Errorf("failureBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// failureQueue is the crawl_failures table of a daotest database
+type failureQueue struct {
+	mu   sync.Mutex
+	rows map[int]carrierleads.CrawlFailure
+}
+
+func newFailureQueue(db *daotest.DB, rows ...carrierleads.CrawlFailure) *failureQueue {
+	q := &failureQueue{rows: map[int]carrierleads.CrawlFailure{}}
+	for _, row := range rows {
+		q.rows[int(row.DotNumber)] = row
+	}
+	db.Handle("ListDueCrawlFailures", func(args []driver.Value) (*daotest.Rows, error) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		now, maxAttempts, lastDot, limit := daotest.Int(args[0]), daotest.Int(args[1]), daotest.Int(args[2]), daotest.Int(args[3])
+		var dots []int
+		for dot, row := range q.rows {
+			if int(row.NextAttemptAt) <= now && int(row.Attempts) < maxAttempts && dot > lastDot {
+				dots = append(dots, dot)
+			}
+		}
+		sort.Ints(dots)
+		if len(dots) > limit {
+			dots = dots[:limit]
+		}
+		var due []interface{}
+		for _, dot := range dots {
+			due = append(due, q.rows[dot])
+		}
+		return daotest.StructRows(due...), nil
+	})
+	db.Handle("RecordCrawlFailure", func(args []driver.Value) (*daotest.Rows, error) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		dot := daotest.Int(args[0])
+		row := q.rows[dot]
+		row.DotNumber = int32(dot)
+		row.Stage, row.ErrorClass = args[1].(string), args[2].(string)
+		row.Attempts++
+		row.LastAttemptAt, row.NextAttemptAt = int32(daotest.Int(args[4])), int32(daotest.Int(args[5]))
+		q.rows[dot] = row
+		return nil, nil
+	})
+	db.Handle("DeleteCrawlFailure", func(args []driver.Value) (*daotest.Rows, error) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.rows, daotest.Int(args[0]))
+		return nil, nil
+	})
+	return q
+}
+
+func (q *failureQueue) get(dot int) (carrierleads.CrawlFailure, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	row, ok := q.rows[dot]
+	return row, ok
+}
+
+func TestCrawler_DrainFailures(t *testing.T) {
+	now := time.Now()
+	ago := int32(now.Add(-time.Minute).Unix())
+	later := int32(now.Add(time.Hour).Unix())
+	f := newFakeSAFER()
+	f.carrier(1, 2, 3, 5)
+	f.statuses[dotKey(2)] = http.StatusForbidden
+	db, d := daotest.New()
+	q := newFailureQueue(db,
+		carrierleads.CrawlFailure{DotNumber: 1, Stage: stageFetch, Attempts: 2, NextAttemptAt: ago},
+		carrierleads.CrawlFailure{DotNumber: 2, Stage: stageFetch, Attempts: 3, NextAttemptAt: ago},
+		carrierleads.CrawlFailure{DotNumber: 3, Stage: stageFetch, Attempts: 1, NextAttemptAt: later},
+		carrierleads.CrawlFailure{DotNumber: 4, Stage: stageFetch, Attempts: 1, NextAttemptAt: ago},
+		carrierleads.CrawlFailure{DotNumber: 5, Stage: stageFetch, Attempts: maxFailureAttempts, NextAttemptAt: ago},
+	)
+	c, sink, stop := testCrawler(f, d)
+	defer stop()
+
+	if err := c.DrainFailures(context.Background()); err != nil {
+		t.Fatalf("DrainFailures() error = %v", err)
+	}
+	// only the due dot numbers that haven't run out of attempts are retried
+	if got, want := f.looked(), []string{dotKey(1), dotKey(2), dotKey(4)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("retried %v, want %v", got, want)
+	}
+	if got := sink.dots(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("stored %v, want [1]", got)
+	}
+	// the carrier stored and the one not found leave the queue
+	for _, dot := range []int{1, 4} {
+		if _, ok := q.get(dot); ok {
+			t.Errorf("dot number %d still queued", dot)
+		}
+	}
+	// the failed retry is rescheduled further away
+	row, ok := q.get(2)
+	if !ok {
+		t.Fatal("dot number 2 left the queue")
+	}
+	if row.Attempts != 4 || row.ErrorClass != "http_403" {
+		t.Errorf("dot number 2 queued with %d attempts and error %s, want 4 and http_403", row.Attempts, row.ErrorClass)
+	}
+	if next := time.Unix(int64(row.NextAttemptAt), 0); next.Before(now.Add(failureBackoff(3)).Add(-time.Second)) {
+		t.Errorf("dot number 2 next attempt at %v, want %v after now", next, failureBackoff(3))
+	}
+	// the others are left as they were
+	for _, dot := range []int{3, 5} {
+		if row, _ := q.get(dot); row.Attempts == 0 || row.LastAttemptAt != 0 {
+			t.Errorf("dot number %d changed: %+v", dot, row)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/lib/safer"
+)
+
+// fakeSAFER serves the SAFER pages of the carriers it knows, the other dot numbers
+// and dockets are not found. The numbers are keyed as "USDOT 123" or "MC_MX 45".
+type fakeSAFER struct {
+	mu sync.Mutex
+	// legal names of the carriers by dot number
+	carriers map[int]string
+	// dot numbers of the carriers by docket number
+	dockets map[int]int
+	// dot numbers found by each search keyword
+	searches map[string][]int
+	// statuses served instead of the page
+	statuses map[string]int
+	// number of 503s served before the page
+	flaky map[string]int
+	// the requests for these numbers wait for unblock, they are sent to started once
+	// they arrive
+	blocked map[string]bool
+	started chan string
+	unblock chan struct{}
+
+	lookups []string
+}
+
+func newFakeSAFER() *fakeSAFER {
+	return &fakeSAFER{
+		carriers: map[int]string{},
+		dockets:  map[int]int{},
+		searches: map[string][]int{},
+		statuses: map[string]int{},
+		flaky:    map[string]int{},
+		blocked:  map[string]bool{},
+		started:  make(chan string, 100),
+		unblock:  make(chan struct{}),
+	}
+}
+
+// carrier adds the carriers with the dot numbers
+func (f *fakeSAFER) carrier(dots ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, dot := range dots {
+		f.carriers[dot] = "CARRIER " + strconv.Itoa(dot)
+	}
+}
+
+// looked returns the numbers looked up, sorted
+func (f *fakeSAFER) looked() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ret := append([]string{}, f.lookups...)
+	sort.Strings(ret)
+	return ret
+}
+
+// count returns how many times key was looked up
+func (f *fakeSAFER) count(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, l := range f.lookups {
+		if l == key {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeSAFER) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if r.URL.Path == "/keywordx.asp" {
+		keyword := strings.Trim(query.Get("searchstring"), "*")
+		f.mu.Lock()
+		dots := f.searches[keyword]
+		f.mu.Unlock()
+		w.Write([]byte(searchPage(dots)))
+		return
+	}
+
+	key := query.Get("query_param") + " " + query.Get("query_string")
+	number, _ := strconv.Atoi(query.Get("query_string"))
+	f.mu.Lock()
+	f.lookups = append(f.lookups, key)
+	blocked, status := f.blocked[key], f.statuses[key]
+	if f.flaky[key] > 0 {
+		f.flaky[key]--
+		status = http.StatusServiceUnavailable
+	}
+	dot := number
+	if query.Get("query_param") == "MC_MX" {
+		dot = f.dockets[number]
+	}
+	name, found := f.carriers[dot]
+	f.mu.Unlock()
+
+	if blocked {
+		f.started <- key
+		select {
+		case <-f.unblock:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	switch {
+	case status != 0:
+		w.WriteHeader(status)
+	case found:
+		w.Write([]byte(snapshotPage(dot, name)))
+	default:
+		w.Write([]byte(`<html><head><title>SAFER Web - Company Snapshot RECORD NOT FOUND</title></head><body></body></html>`))
+	}
+}
+
+// snapshotPage is a SAFER snapshot page with just enough of its layout for the
+// carrier to be parsed
+func snapshotPage(dot int, name string) string {
+	var rows strings.Builder
+	for i := 1; i <= 8; i++ {
+		switch i {
+		case 3:
+			rows.WriteString("<tr><td>AUTHORIZED FOR Property</td><td></td></tr>")
+		case 4:
+			rows.WriteString("<tr><td>" + name + "</td></tr>")
+		default:
+			rows.WriteString("<tr><td></td></tr>")
+		}
+	}
+	fmt.Fprintf(&rows, "<tr><td>%d</td><td></td></tr>", dot)
+	return "<html><head><title>SAFER Web - Company Snapshot " + name + "</title></head><body><p><table>" +
+		"<tr><td></td></tr><tr><td><table><tr><td></td></tr><tr><td>" +
+		"<center><table>" + rows.String() + "</table></center>" +
+		"</td></tr></table></td></tr></table></p></body></html>"
+}
+
+// searchPage is a SAFER search result page listing the carriers with the dot numbers
+func searchPage(dots []int) string {
+	var rows strings.Builder
+	for _, dot := range dots {
+		fmt.Fprintf(&rows, `<tr><th scope="rpw"><b><a href="query.asp?searchtype=ANY&query_type=queryCarrierSnapshot&query_param=USDOT&query_string=%d">CARRIER %d</a></b></th><td><b>GREEN BAY, WI</b></td></tr>`, dot, dot)
+	}
+	return "<html><body><table></table><table></table><table>" + rows.String() + "</table></body></html>"
+}
+
+// memSink records the dot numbers of the snapshots written to it. The dot numbers in
+// fail fail to be written.
+type memSink struct {
+	mu      sync.Mutex
+	fail    map[int]bool
+	written []int
+}
+
+func (m *memSink) Write(ctx context.Context, s *safer.CompanySnapshot) error {
+	dot, _ := strconv.Atoi(s.DOTNumber)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail[dot] {
+		return errors.New("disk full")
+	}
+	m.written = append(m.written, dot)
+	return nil
+}
+
+func (m *memSink) Close() error {
+	return nil
+}
+
+// dots returns the dot numbers written, sorted
+func (m *memSink) dots() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ret := append([]int{}, m.written...)
+	sort.Ints(ret)
+	return ret
+}
+
+// testCrawler returns a crawler fetching from f with up to 4 workers and writing to a
+// memSink, with its state in d
+func testCrawler(f *fakeSAFER, d dao.Dao) (*Crawler, *memSink, func()) {
+	srv := httptest.NewServer(f)
+	sink := &memSink{}
+	c := &Crawler{
+		Client:      safer.NewClient(safer.WithBaseURL(srv.URL)),
+		Concurrency: NewConcurrencyController(4, 4),
+		Dao:         d,
+		Sink:        sink,
+	}
+	return c, sink, srv.Close
+}
+
+// dotKey is the key of a dot number in fakeSAFER
+func dotKey(dot int) string {
+	return "USDOT " + strconv.Itoa(dot)
+}
+
+// docketKey is the key of a docket number in fakeSAFER
+func docketKey(docket int) string {
+	return "MC_MX " + strconv.Itoa(docket)
+}
@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"encoding/json"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+)
+
+// snapshotWrite builds the database write of s. Whether it changes the stored
+// carrier is told by diffStored when the write is stored.
+func snapshotWrite(s *safer.CompanySnapshot) (w dao.SnapshotWrite, err error) {
+	w.Params, err = snapshotParams(s)
+	w.Inactive = s.InactiveDate != nil
+	return
+}
+
+// diffStored is the dao.Differ of the crawler. It tells whether w changes stored, and
+// so needs a new version in the history, and which events the change makes. No event
+// is derived for an inactive carrier.
+func diffStored(stored *carrierleads.FmcsaCarrierSafer, w *dao.SnapshotWrite) (err error) {
+	w.Changed, w.Events = false, nil
+	switch {
+	case stored == nil:
+		// a new carrier has no version to close
+		if !w.Inactive {
+			w.Events, err = eventParams(carrierEvents(nil, storedSnapshot(w.Params)))
+		}
+	case w.Inactive:
+		// only the status and inactive date of an inactive carrier are written
+		w.Changed = stored.OperatingStatus != w.Params.OperatingStatus || !stored.InactiveDate.Valid
+	default:
+		w.Changed = len(storedChanges(*stored, w.Params)) > 0
+		w.Events, err = eventParams(carrierEvents(SnapshotFromRow(*stored), storedSnapshot(w.Params)))
+	}
+	return
+}
+
+// eventParams builds the rows of the carrier_events table for events detected now
+func eventParams(events []Event) ([]carrierleads.CreateCarrierEventParams, error) {
+	var params []carrierleads.CreateCarrierEventParams
+	now := int32(time.Now().Unix())
+	for _, e := range events {
+		details, err := json.Marshal(e.Changes)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, carrierleads.CreateCarrierEventParams{
+			DotNumber:  int32(e.DOTNumber),
+			EventType:  string(e.Type),
+			Details:    details,
+			OccurredAt: now,
+		})
+	}
+	return params, nil
+}
+
+// storedChanges returns how params changes row, the carrier stored, other than by
+// when SAFER last updated the carrier. Both are compared as they read back from the
+// database.
+func storedChanges(row carrierleads.FmcsaCarrierSafer, params carrierleads.CreateSaferSnapshotParams) []safer.Change {
+	return safer.DiffSnapshots(SnapshotFromRow(row), storedSnapshot(params), safer.IgnoreVolatile())
+}
+
+// storedSnapshot returns the snapshot written with params as it reads back from the
+// database
+func storedSnapshot(params carrierleads.CreateSaferSnapshotParams) *safer.CompanySnapshot {
+	return SnapshotFromRow(carrierleads.FmcsaCarrierSafer(params))
+}
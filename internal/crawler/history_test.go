@@ -0,0 +1,96 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+)
+
+func Test_storedChanges(t *testing.T) {
+	date := time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC)
+	later := date.AddDate(0, 3, 0)
+	stored := &safer.CompanySnapshot{
+		DOTNumber:        "123",
+		LegalName:        "ACME TRUCKING LLC",
+		OperatingStatus:  "AUTHORIZED FOR Property",
+		CargoCarried:     []string{"General Freight", "Household Goods"},
+		LatestUpdateDate: &date,
+		PowerUnits:       3,
+	}
+	params, err := snapshotParams(stored)
+	if err != nil {
+		t.Fatalf("snapshotParams() error = %v", err)
+	}
+	row := carrierleads.FmcsaCarrierSafer(params)
+
+	tests := []struct {
+		name   string
+		modify func(s *safer.CompanySnapshot)
+		want   bool
+	}{
+		{name: "same", modify: func(s *safer.CompanySnapshot) {}, want: false},
+		{name: "updated by SAFER", modify: func(s *safer.CompanySnapshot) { s.LatestUpdateDate = &later }, want: false},
+		{name: "fleet size", modify: func(s *safer.CompanySnapshot) { s.PowerUnits = 6 }, want: true},
+		{name: "out of service", modify: func(s *safer.CompanySnapshot) { s.OutOfServiceDate = &later }, want: true},
+		{name: "cargo", modify: func(s *safer.CompanySnapshot) { s.CargoCarried = append(s.CargoCarried, "Grain, Feed, Hay") }, want: true},
+		{name: "cargo reordered", modify: func(s *safer.CompanySnapshot) { s.CargoCarried = []string{"Household Goods", "General Freight"} }, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := *stored
+			tt.modify(&s)
+			params, err := snapshotParams(&s)
+			if err != nil {
+				t.Fatalf("snapshotParams() error = %v", err)
+			}
+			if got := storedChanges(row, params); (len(got) > 0) != tt.want {
+				t.Errorf("storedChanges() = %+v, want changes %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_diffStored(t *testing.T) {
+	date := time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC)
+	s := &safer.CompanySnapshot{
+		DOTNumber:       "123",
+		LegalName:       "ACME TRUCKING LLC",
+		OperatingStatus: "AUTHORIZED FOR Property",
+		PowerUnits:      3,
+	}
+	w, err := snapshotWrite(s)
+	if err != nil {
+		t.Fatalf("snapshotWrite() error = %v", err)
+	}
+	if err = diffStored(nil, &w); err != nil {
+		t.Fatalf("diffStored() error = %v", err)
+	}
+	if len(w.Events) != 1 || w.Events[0].EventType != string(EventNewCarrier) || w.Changed {
+		t.Errorf("diffStored() of a new carrier = %+v, changed %v, want a new_carrier event", w.Events, w.Changed)
+	}
+
+	// the carrier written again, e.g. found twice in a batch, makes no event
+	row := carrierleads.FmcsaCarrierSafer(w.Params)
+	if err = diffStored(&row, &w); err != nil {
+		t.Fatalf("diffStored() error = %v", err)
+	}
+	if len(w.Events) != 0 || w.Changed {
+		t.Errorf("diffStored() of the same carrier = %+v, changed %v, want no change", w.Events, w.Changed)
+	}
+
+	inactive := *s
+	inactive.OperatingStatus = "INACTIVE USDOT Number"
+	inactive.InactiveDate = &date
+	w, err = snapshotWrite(&inactive)
+	if err != nil {
+		t.Fatalf("snapshotWrite() error = %v", err)
+	}
+	if err = diffStored(&row, &w); err != nil {
+		t.Fatalf("diffStored() error = %v", err)
+	}
+	if !w.Inactive || !w.Changed || len(w.Events) != 0 {
+		t.Errorf("diffStored() of an inactive carrier = %+v, changed %v, want a change without events", w.Events, w.Changed)
+	}
+}
@@ -0,0 +1,238 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+// IdentifierKind tells which SAFER number an Identifier is
+type IdentifierKind string
+
+const (
+	// DOTIdentifier is a USDOT number
+	DOTIdentifier IdentifierKind = "dot"
+	// MCMXIdentifier is an MC/MX docket number
+	MCMXIdentifier IdentifierKind = "mc"
+)
+
+// Identifier is a USDOT or MC/MX number read from a list
+type Identifier struct {
+	Kind   IdentifierKind
+	Number int
+}
+
+func (id Identifier) String() string {
+	if id.Kind == MCMXIdentifier {
+		return "MC-" + strconv.Itoa(id.Number)
+	}
+	return "USDOT " + strconv.Itoa(id.Number)
+}
+
+// ParseIdentifier parses a USDOT or MC/MX number. A DOT, USDOT, MC or MX prefix,
+// optionally followed by a dash or a space, selects the kind, otherwise the number is
+// of kind def.
+func ParseIdentifier(text string, def IdentifierKind) (Identifier, error) {
+	id := Identifier{Kind: def}
+	number := strings.ToUpper(strings.TrimSpace(text))
+	for _, prefix := range []struct {
+		text string
+		kind IdentifierKind
+	}{
+		{"USDOT", DOTIdentifier},
+		{"DOT", DOTIdentifier},
+		{"MC", MCMXIdentifier},
+		{"MX", MCMXIdentifier},
+	} {
+		if strings.HasPrefix(number, prefix.text) {
+			id.Kind = prefix.kind
+			number = strings.TrimLeft(number[len(prefix.text):], "- #")
+			break
+		}
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 {
+		return id, fmt.Errorf("invalid identifier %q", text)
+	}
+	id.Number = n
+	return id, nil
+}
+
+// ReadIdentifiers reads the identifiers in r, either one per line or, if column is
+// set, from the CSV column with that header. Blank entries are skipped and duplicates
+// are dropped, keeping the order of first appearance. The entries that aren't
+// identifiers are skipped too and returned in invalid, along with their line. Only a
+// list that can't be read, such as a CSV without the column, is an error.
+func ReadIdentifiers(r io.Reader, column string, def IdentifierKind) (ids []Identifier, invalid []string, err error) {
+	type entry struct {
+		line  int
+		value string
+		err   error
+	}
+	var entries []entry
+	if column == "" {
+		scanner := bufio.NewScanner(r)
+		for line := 1; scanner.Scan(); line++ {
+			entries = append(entries, entry{line: line, value: scanner.Text()})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		header, err := cr.Read()
+		if err != nil {
+			return nil, nil, err
+		}
+		index := -1
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, nil, fmt.Errorf("column %q not found", column)
+		}
+		for {
+			record, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				entries = append(entries, entry{line: parseErr.StartLine, err: parseErr.Err})
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if index < len(record) {
+				line, _ := cr.FieldPos(index)
+				entries = append(entries, entry{line: line, value: record[index]})
+			}
+		}
+	}
+
+	seen := map[Identifier]struct{}{}
+	for _, e := range entries {
+		if e.err == nil && strings.TrimSpace(e.value) == "" {
+			continue
+		}
+		var id Identifier
+		err := e.err
+		if err == nil {
+			id, err = ParseIdentifier(e.value, def)
+		}
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("line %d: %v", e.line, err))
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids, invalid, nil
+}
+
+// ListSummary sums up the outcome of CrawlList
+type ListSummary struct {
+	// Hits is the number of carriers found and stored
+	Hits int
+	// Missed lists the identifiers SAFER has no active carrier for
+	Missed []Identifier
+	// Failed lists the identifiers that couldn't be fetched or stored
+	Failed []Identifier
+	// Invalid lists the entries of the list that aren't identifiers, as returned by
+	// ReadIdentifiers
+	Invalid []string
+}
+
+// CrawlList fetches the carriers for ids and stores them in the database. DOT numbers
+// are looked up with GetCompanyByDOTNumber and MC/MX numbers with GetCompanyByMCMX.
+// Inactive DOT numbers are stored as inactive carriers, inactive MC/MX numbers count
+// as misses since SAFER doesn't tell their DOT number. An interrupted crawl returns
+// the summary so far.
+func (c *Crawler) CrawlList(ctx context.Context, ids []Identifier) (summary ListSummary, err error) {
+	cc := c.Concurrency
+	dbCtx := context.Background()
+
+	var mu sync.Mutex
+	outcome := func(id Identifier, hit bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case hit:
+			summary.Hits++
+		case errors.Is(err, safer.ErrCompanyNotFound) || errors.Is(err, safer.ErrRecordInactive):
+			summary.Missed = append(summary.Missed, id)
+		default:
+			summary.Failed = append(summary.Failed, id)
+		}
+	}
+
+	workers := newPool(cc.max)
+	for _, id := range ids {
+		id := id
+		err = c.submit(ctx, workers, func() {
+			var s *safer.CompanySnapshot
+			var err error
+			if id.Kind == MCMXIdentifier {
+				s, err = fetchWithRetry(ctx, cc, strconv.Itoa(id.Number), c.Client.GetCompanyByMCMXContext)
+				tally.lookup(0, err)
+			} else {
+				s, err = c.getSaferSnapshot(ctx, id.Number)
+			}
+			if err != nil && ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				switch {
+				case errors.Is(err, safer.ErrCompanyNotFound) || errors.Is(err, safer.ErrRecordInactive):
+				case id.Kind == DOTIdentifier:
+					c.recordFailure(dbCtx, id.Number, fetchStage(err), err, time.Now())
+				default:
+					log.WithField("docket", id.Number).WithFields(failureFields(fetchStage(err), err)).Warn("failed to get snapshot")
+					tally.failure()
+				}
+				outcome(id, false, err)
+				return
+			}
+
+			dotNumber, _ := strconv.Atoi(s.DOTNumber)
+			c.write(dbCtx, dotNumber, s, func(err error) {
+				if err != nil {
+					if dotNumber > 0 {
+						c.recordFailure(dbCtx, dotNumber, stageWrite, err, time.Now())
+					} else {
+						log.WithField("identifier", id.String()).WithFields(failureFields(stageWrite, err)).Warn("failed to write result")
+						tally.failure()
+					}
+				}
+				outcome(id, err == nil, err)
+			})
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	err = c.finish(ctx, workers, nil)
+	// the requests given up on may still be running
+	mu.Lock()
+	defer mu.Unlock()
+	return summary, err
+}
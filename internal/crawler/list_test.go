@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"carrierleads.com/internal/dao/daotest"
+)
+
+func TestParseIdentifier(t *testing.T) {
+	tests := []struct {
+		text    string
+		def     IdentifierKind
+		want    Identifier
+		wantErr bool
+	}{
+		{text: "123", def: DOTIdentifier, want: Identifier{DOTIdentifier, 123}},
+		{text: "123", def: MCMXIdentifier, want: Identifier{MCMXIdentifier, 123}},
+		{text: " MC-456 ", def: DOTIdentifier, want: Identifier{MCMXIdentifier, 456}},
+		{text: "mc 456", def: DOTIdentifier, want: Identifier{MCMXIdentifier, 456}},
+		{text: "MX789", def: DOTIdentifier, want: Identifier{MCMXIdentifier, 789}},
+		{text: "USDOT 123", def: MCMXIdentifier, want: Identifier{DOTIdentifier, 123}},
+		{text: "DOT#123", def: MCMXIdentifier, want: Identifier{DOTIdentifier, 123}},
+		{text: "MC-", def: DOTIdentifier, wantErr: true},
+		{text: "abc", def: DOTIdentifier, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseIdentifier(tt.text, tt.def)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseIdentifier(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseIdentifier(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestReadIdentifiers(t *testing.T) {
+	lines := "123\n\nMC-456\nabc\n123\nmc 456\n"
+	got, invalid, err := ReadIdentifiers(strings.NewReader(lines), "", DOTIdentifier)
+	if err != nil {
+		t.Fatalf("ReadIdentifiers() error = %v", err)
+	}
+	if want := []Identifier{{DOTIdentifier, 123}, {MCMXIdentifier, 456}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadIdentifiers() = %v, want %v", got, want)
+	}
+	if want := []string{`line 4: invalid identifier "abc"`}; !reflect.DeepEqual(invalid, want) {
+		t.Errorf("ReadIdentifiers() invalid = %q, want %q", invalid, want)
+	}
+
+	csv := "name,Docket\nACME,MC-1\n\"Foo, Inc\",2\nBar,\nBaz,1\nQux,MC-\n\"Quux,3\n"
+	got, invalid, err = ReadIdentifiers(strings.NewReader(csv), "docket", MCMXIdentifier)
+	if err != nil {
+		t.Fatalf("ReadIdentifiers() csv error = %v", err)
+	}
+	if want := []Identifier{{MCMXIdentifier, 1}, {MCMXIdentifier, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadIdentifiers() csv = %v, want %v", got, want)
+	}
+	if want := []string{`line 6: invalid identifier "MC-"`, `line 7: extraneous or missing " in quoted-field`}; !reflect.DeepEqual(invalid, want) {
+		t.Errorf("ReadIdentifiers() csv invalid = %q, want %q", invalid, want)
+	}
+
+	if _, _, err = ReadIdentifiers(strings.NewReader(csv), "dot", DOTIdentifier); err == nil {
+		t.Error("ReadIdentifiers() with a missing column should fail")
+	}
+}
+
+func TestCrawler_CrawlList(t *testing.T) {
+	f := newFakeSAFER()
+	f.carrier(1, 3, 11)
+	f.dockets[10] = 11
+	f.statuses[dotKey(3)] = http.StatusForbidden
+	_, d := daotest.New()
+	c, sink, stop := testCrawler(f, d)
+	defer stop()
+
+	ids, invalid, err := ReadIdentifiers(strings.NewReader("1\n2\nMC-10\n3\nMC-20\nx\n"), "", DOTIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := c.CrawlList(context.Background(), ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(summary.Missed, func(i, j int) bool { return summary.Missed[i].Kind < summary.Missed[j].Kind })
+	want := ListSummary{
+		Hits:   2,
+		Missed: []Identifier{{DOTIdentifier, 2}, {MCMXIdentifier, 20}},
+		Failed: []Identifier{{DOTIdentifier, 3}},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("CrawlList() = %+v, want %+v", summary, want)
+	}
+	if got := sink.dots(); !reflect.DeepEqual(got, []int{1, 11}) {
+		t.Errorf("wrote %v, want the carriers of USDOT 1 and MC-10", got)
+	}
+	if len(invalid) != 1 {
+		t.Errorf("invalid %q, want the line x", invalid)
+	}
+}
@@ -0,0 +1,22 @@
+package crawler
+
+import (
+	"errors"
+
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+// failureFields describes a failure at stage for the logs
+func failureFields(stage string, err error) log.Fields {
+	fields := log.Fields{
+		"stage":       stage,
+		"error_class": errorClass(err),
+		log.ErrorKey:  err,
+	}
+	var statusErr *safer.StatusError
+	if errors.As(err, &statusErr) {
+		fields["status_code"] = statusErr.StatusCode
+	}
+	return fields
+}
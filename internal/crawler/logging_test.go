@@ -0,0 +1,22 @@
+package crawler
+
+import (
+	"errors"
+	"testing"
+
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_failureFields(t *testing.T) {
+	err := &safer.StatusError{StatusCode: 429}
+	fields := failureFields(stageFetch, err)
+	if fields["stage"] != stageFetch || fields["error_class"] != "http_429" || fields["status_code"] != 429 || fields[log.ErrorKey] != err {
+		t.Errorf("failureFields() = %v", fields)
+	}
+
+	fields = failureFields(stageWrite, errors.New("deadlock"))
+	if _, ok := fields["status_code"]; ok {
+		t.Errorf("failureFields() = %v, want no status_code", fields)
+	}
+}
@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"errors"
+	"time"
+
+	"carrierleads.com/internal/lib/safer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics, served by the crawl command when a metrics address is set
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crawler_safer_requests_total",
+		Help: "Requests made to SAFER by outcome.",
+	}, []string{"outcome"})
+	requestDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "crawler_safer_request_duration_seconds",
+		Help:    "Latency of the requests made to SAFER.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+	})
+	retriesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "crawler_safer_retries_total",
+		Help: "Requests to SAFER retried after a temporary failure.",
+	})
+	writeDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "crawler_db_write_duration_seconds",
+		Help:    "Latency of the carrier writes to the database.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+	})
+	writeFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "crawler_db_write_failures_total",
+		Help: "Carrier writes to the database that failed.",
+	})
+	webhookDeliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crawler_webhook_deliveries_total",
+		Help: "Carrier events delivered to webhooks by outcome.",
+	}, []string{"outcome"})
+	cursor = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "crawler_cursor",
+		Help: "Last number dispatched by a sweep.",
+	}, []string{"sweep"})
+	inflightWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "crawler_inflight_workers",
+		Help: "Workers currently talking to SAFER.",
+	})
+	queueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "crawler_queue_depth",
+		Help: "Workers waiting for a concurrency slot.",
+	})
+	concurrencyTarget = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "crawler_concurrency_target",
+		Help: "Number of workers allowed to talk to SAFER.",
+	})
+)
+
+// observeRequest records the outcome and latency of a request to SAFER
+func observeRequest(latency time.Duration, err error) {
+	requestDuration.Observe(latency.Seconds())
+	requestsTotal.WithLabelValues(requestOutcome(err)).Inc()
+}
+
+func requestOutcome(err error) string {
+	var statusErr *safer.StatusError
+	var parseErr *safer.ParseError
+	switch {
+	case err == nil:
+		return "found"
+	case errors.Is(err, safer.ErrCompanyNotFound):
+		return "not_found"
+	case errors.Is(err, safer.ErrRecordInactive):
+		return "inactive"
+	case errors.As(err, &statusErr):
+		return "http_error"
+	case errors.As(err, &parseErr):
+		return "parse_error"
+	default:
+		return "transport_error"
+	}
+}
+
+// observeWrite records the latency and outcome of a carrier write
+func observeWrite(latency time.Duration, err error) {
+	writeDuration.Observe(latency.Seconds())
+	if err != nil {
+		writeFailuresTotal.Inc()
+	}
+}
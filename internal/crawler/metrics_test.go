@@ -0,0 +1,27 @@
+package crawler
+
+import (
+	"errors"
+	"testing"
+
+	"carrierleads.com/internal/lib/safer"
+)
+
+func Test_requestOutcome(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: "found"},
+		{err: safer.ErrCompanyNotFound, want: "not_found"},
+		{err: &safer.RecordInactiveError{}, want: "inactive"},
+		{err: &safer.StatusError{StatusCode: 503}, want: "http_error"},
+		{err: &safer.ParseError{Err: errors.New("no dot number")}, want: "parse_error"},
+		{err: &safer.TransportError{Err: errors.New("connection reset")}, want: "transport_error"},
+	}
+	for _, tt := range tests {
+		if got := requestOutcome(tt.err); got != tt.want {
+			t.Errorf("requestOutcome(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+	log "github.com/sirupsen/logrus"
+)
+
+// headers of the webhook requests
+const (
+	TimestampHeader = "X-Carrierleads-Timestamp"
+	SignatureHeader = "X-Carrierleads-Signature"
+)
+
+const (
+	webhookWorkers  = 4
+	webhookQueue    = 1000
+	webhookAttempts = 5
+	webhookBackoff  = time.Second
+	webhookTimeout  = 10 * time.Second
+)
+
+// errors recorded for the events that couldn't be delivered
+var (
+	errWebhookQueueFull = errors.New("webhook queue full")
+	errWebhookShutdown  = errors.New("shutting down before the webhook was delivered")
+)
+
+// Webhook is an endpoint the carrier events are POSTed to
+type Webhook struct {
+	URL string
+	// Secret signs the payloads, they are sent unsigned if it is empty
+	Secret string
+	// Events are the types of the events delivered, all of them if empty
+	Events []EventType
+}
+
+func (w Webhook) wants(t EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the JSON body POSTed for an event
+type WebhookPayload struct {
+	Type       EventType       `json:"type"`
+	DOTNumber  int             `json:"dot_number"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Changes    json.RawMessage `json:"changes"`
+}
+
+// WebhookSignature signs the body of a request sent at timestamp, the unix time in
+// the TimestampHeader. It is the hex HMAC-SHA256, keyed with secret, of the timestamp,
+// a dot and the body.
+func WebhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notifier POSTs the carrier events stored by a crawl to webhooks, each event in its
+// own request. A request failing on the network, with a 429 or a 5xx response is
+// retried with an exponential backoff. The outcome of every delivery is recorded in
+// the webhook_deliveries table when Dao is connected to a database.
+//
+// The events are delivered in the background by a few workers. Queueing them never
+// blocks the crawl, the events queued while the workers are too far behind are
+// recorded as failed instead.
+type Notifier struct {
+	hooks    []Webhook
+	dao      dao.Dao
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+	grace    time.Duration
+
+	queue chan delivery
+	wg    sync.WaitGroup
+	// closed once Close is called, the failed deliveries aren't retried afterwards
+	closing chan struct{}
+	// canceled once the grace given to Close has passed, aborting the requests in
+	// flight
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type delivery struct {
+	hook  Webhook
+	event EventType
+	dot   int
+	body  []byte
+}
+
+// NewNotifier returns a notifier delivering to hooks, it must be closed once the
+// crawl is done
+func NewNotifier(d dao.Dao, hooks []Webhook) *Notifier {
+	n := &Notifier{
+		hooks:    hooks,
+		dao:      d,
+		client:   &http.Client{Timeout: webhookTimeout},
+		attempts: webhookAttempts,
+		backoff:  webhookBackoff,
+		grace:    shutdownGrace,
+		queue:    make(chan delivery, webhookQueue),
+		closing:  make(chan struct{}),
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	for i := 0; i < webhookWorkers; i++ {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			for d := range n.queue {
+				n.deliver(d)
+			}
+		}()
+	}
+	return n
+}
+
+// notify queues the delivery of events to the webhooks wanting them, the deliveries
+// that don't fit in the queue are recorded as failed. A nil notifier drops them.
+func (n *Notifier) notify(events []carrierleads.CreateCarrierEventParams) {
+	if n == nil {
+		return
+	}
+	for _, e := range events {
+		body, err := json.Marshal(WebhookPayload{
+			Type:       EventType(e.EventType),
+			DOTNumber:  int(e.DotNumber),
+			OccurredAt: time.Unix(int64(e.OccurredAt), 0).UTC(),
+			Changes:    e.Details,
+		})
+		if err != nil {
+			log.WithError(err).WithField("dot", e.DotNumber).Error("failed to encode webhook payload")
+			continue
+		}
+		for _, hook := range n.hooks {
+			if hook.wants(EventType(e.EventType)) {
+				d := delivery{hook: hook, event: EventType(e.EventType), dot: int(e.DotNumber), body: body}
+				select {
+				case n.queue <- d:
+				default:
+					n.record(d, 0, 0, errWebhookQueueFull)
+				}
+			}
+		}
+	}
+}
+
+// Close waits for the queued events to be delivered, without retrying the failed
+// ones, for up to shutdownGrace. The requests still in flight are then aborted and
+// the events left are recorded as failed. The notifier can't be used afterwards.
+func (n *Notifier) Close() {
+	close(n.closing)
+	close(n.queue)
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(n.grace):
+		log.WithFields(log.Fields{"grace": n.grace, "pending": len(n.queue)}).Warn("webhooks not delivered in time")
+		n.cancel()
+		<-done
+	}
+	n.cancel()
+}
+
+func (n *Notifier) deliver(d delivery) {
+	var status, attempts int
+	var err error
+	backoff := n.backoff
+retry:
+	for attempts < n.attempts {
+		if n.ctx.Err() != nil {
+			err = errWebhookShutdown
+			break
+		}
+		attempts++
+		status, err = n.post(d)
+		if err != nil && n.ctx.Err() != nil {
+			err = errWebhookShutdown
+		}
+		if err == nil || !retryableDelivery(status) || attempts == n.attempts {
+			break
+		}
+		select {
+		case <-n.closing:
+			break retry
+		default:
+		}
+		log.WithError(err).WithFields(log.Fields{
+			"url":         d.hook.URL,
+			"attempt":     attempts,
+			"status_code": status,
+			"backoff":     backoff,
+		}).Warn("retrying webhook delivery")
+		select {
+		case <-time.After(backoff):
+		case <-n.closing:
+			break retry
+		}
+		backoff *= 2
+	}
+	n.record(d, attempts, status, err)
+}
+
+// record logs the outcome of d and stores it in the webhook_deliveries table, err is
+// nil if d was delivered
+func (n *Notifier) record(d delivery, attempts, status int, err error) {
+	entry := log.WithFields(log.Fields{"url": d.hook.URL, "event": d.event, "dot": d.dot, "attempts": attempts})
+	message := ""
+	if err != nil {
+		entry.WithError(err).Warn("webhook delivery failed")
+		webhookDeliveriesTotal.WithLabelValues("failed").Inc()
+		message = err.Error()
+		if len(message) > maxFailureMessage {
+			message = message[:maxFailureMessage]
+		}
+	} else {
+		entry.Debug("webhook delivered")
+		webhookDeliveriesTotal.WithLabelValues("delivered").Inc()
+	}
+	if !hasDatabase(n.dao) {
+		return
+	}
+	err = n.dao.Queries.CreateWebhookDelivery(context.Background(), carrierleads.CreateWebhookDeliveryParams{
+		Url:        d.hook.URL,
+		EventType:  string(d.event),
+		DotNumber:  int32(d.dot),
+		Attempts:   int32(attempts),
+		StatusCode: int32(status),
+		LastError:  message,
+		Delivered:  message == "",
+		CreatedAt:  int32(time.Now().Unix()),
+	})
+	if err != nil {
+		entry.WithError(err).Error("failed to record webhook delivery")
+	}
+}
+
+// post sends d once and returns the status code of the response, 0 if there was none.
+// A response other than a 2xx is an error.
+func (n *Notifier) post(d delivery) (int, error) {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, d.hook.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.hook.Secret != "" {
+		timestamp := time.Now().Unix()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(SignatureHeader, WebhookSignature(d.hook.Secret, timestamp, d.body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryableDelivery tells whether a delivery that failed with status, 0 if there was
+// no response, may succeed on retry
+func retryableDelivery(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
@@ -0,0 +1,202 @@
+package crawler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+)
+
+// receiver is a webhook endpoint recording the requests it gets, it responds with
+// the statuses in order and then 200
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	if len(r.statuses) > 0 {
+		w.WriteHeader(r.statuses[0])
+		r.statuses = r.statuses[1:]
+	}
+}
+
+// wait waits for the receiver to get n requests, Close stops retrying the deliveries
+func (r *receiver) wait(t *testing.T, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		r.mu.Lock()
+		got := len(r.requests)
+		r.mu.Unlock()
+		if got >= n {
+			return
+		}
+	}
+	t.Fatalf("receiver didn't get %d requests", n)
+}
+
+// hang is a webhook endpoint that never responds
+func hang(w http.ResponseWriter, req *http.Request) {
+	// the request is only canceled once its body is read
+	io.Copy(io.Discard, req.Body)
+	<-req.Context().Done()
+}
+
+func testNotifier(hooks ...Webhook) *Notifier {
+	n := NewNotifier(dao.Dao{}, hooks)
+	n.backoff = time.Millisecond
+	return n
+}
+
+var testEvents = []carrierleads.CreateCarrierEventParams{
+	{DotNumber: 123, EventType: string(EventOutOfService), Details: json.RawMessage(`[{"field":"operating_status","old":"AUTHORIZED","new":"OUT-OF-SERVICE"}]`), OccurredAt: 1667001600},
+	{DotNumber: 456, EventType: string(EventFleetDoubled), Details: json.RawMessage(`[{"field":"power_units","old":2,"new":4}]`), OccurredAt: 1667001600},
+}
+
+func TestNotifier_signedPayloads(t *testing.T) {
+	all, filtered := &receiver{}, &receiver{}
+	allSrv, filteredSrv := httptest.NewServer(all), httptest.NewServer(filtered)
+	defer allSrv.Close()
+	defer filteredSrv.Close()
+
+	n := testNotifier(
+		Webhook{URL: allSrv.URL, Secret: "s3cret"},
+		Webhook{URL: filteredSrv.URL, Events: []EventType{EventFleetDoubled}},
+	)
+	n.notify(testEvents)
+	n.Close()
+
+	if len(all.bodies) != 2 {
+		t.Fatalf("webhook without filter got %d requests, want 2", len(all.bodies))
+	}
+	for i, req := range all.requests {
+		timestamp, err := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			t.Fatalf("%s = %q", TimestampHeader, req.Header.Get(TimestampHeader))
+		}
+		if got, want := req.Header.Get(SignatureHeader), WebhookSignature("s3cret", timestamp, all.bodies[i]); got != want {
+			t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+		}
+	}
+
+	if len(filtered.bodies) != 1 {
+		t.Fatalf("filtered webhook got %d requests, want 1", len(filtered.bodies))
+	}
+	if filtered.requests[0].Header.Get(SignatureHeader) != "" {
+		t.Errorf("webhook without secret got a signature")
+	}
+	var got WebhookPayload
+	if err := json.Unmarshal(filtered.bodies[0], &got); err != nil {
+		t.Fatalf("payload %s: %v", filtered.bodies[0], err)
+	}
+	if got.Type != EventFleetDoubled || got.DOTNumber != 456 || !got.OccurredAt.Equal(time.Unix(1667001600, 0)) {
+		t.Errorf("payload = %+v", got)
+	}
+	if string(got.Changes) != string(testEvents[1].Details) {
+		t.Errorf("payload changes = %s, want %s", got.Changes, testEvents[1].Details)
+	}
+}
+
+func TestNotifier_retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     int
+	}{
+		{name: "delivered", want: 1},
+		{name: "server errors", statuses: []int{500, 503}, want: 3},
+		{name: "rate limited", statuses: []int{429}, want: 2},
+		{name: "rejected", statuses: []int{400}, want: 1},
+		{name: "gives up", statuses: []int{500, 500, 500, 500, 500, 500}, want: webhookAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &receiver{statuses: tt.statuses}
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+
+			n := testNotifier(Webhook{URL: srv.URL})
+			n.notify(testEvents[:1])
+			r.wait(t, tt.want)
+			n.Close()
+			if len(r.requests) != tt.want {
+				t.Errorf("got %d requests, want %d", len(r.requests), tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifier_nil(t *testing.T) {
+	var n *Notifier
+	n.notify(testEvents)
+}
+
+func TestNotifier_closeStopsRetrying(t *testing.T) {
+	r := &receiver{statuses: []int{500, 500, 500, 500}}
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	n := testNotifier(Webhook{URL: srv.URL})
+	n.backoff = time.Hour
+	n.notify(testEvents[:1])
+	r.wait(t, 1)
+	start := time.Now()
+	n.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close() took %v, want the backoff to be cut short", elapsed)
+	}
+	if len(r.requests) != 1 {
+		t.Errorf("got %d requests, want 1", len(r.requests))
+	}
+}
+
+func TestNotifier_closeDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(hang))
+	defer srv.Close()
+
+	n := testNotifier(Webhook{URL: srv.URL})
+	n.grace = 10 * time.Millisecond
+	n.notify(testEvents)
+	start := time.Now()
+	n.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close() took %v, want it to give up after the grace", elapsed)
+	}
+}
+
+func TestNotifier_queueFull(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(hang))
+	defer srv.Close()
+
+	n := testNotifier(Webhook{URL: srv.URL})
+	n.grace = 10 * time.Millisecond
+	events := make([]carrierleads.CreateCarrierEventParams, webhookWorkers+webhookQueue+10)
+	for i := range events {
+		events[i] = testEvents[0]
+	}
+	done := make(chan struct{})
+	go func() {
+		n.notify(events)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("notify() blocked on the full queue")
+	}
+	n.Close()
+}
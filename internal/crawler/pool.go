@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// how long an interrupted crawl waits for the tasks in flight before giving up on them
+const shutdownGrace = 30 * time.Second
+
+// pool runs submitted tasks on a fixed number of goroutines.
+type pool struct {
+	c  chan func()
+	wg sync.WaitGroup
+	// counts the tasks submitted and not done yet
+	tasks sync.WaitGroup
+}
+
+func newPool(size int) *pool {
+	p := &pool{c: make(chan func())}
+	poll := func() {
+		defer p.wg.Done()
+		for {
+			t1, more := <-p.c
+			if more {
+				t1()
+			} else {
+				return
+			}
+		}
+	}
+
+	for i := 0; i < size; i++ {
+		p.wg.Add(1)
+		go poll()
+	}
+	return p
+}
+
+// submit blocks until a worker picks up the task or ctx is done.
+func (p *pool) submit(ctx context.Context, task func()) error {
+	p.tasks.Add(1)
+	select {
+	case p.c <- func() {
+		defer p.tasks.Done()
+		task()
+	}:
+		return nil
+	case <-ctx.Done():
+		p.tasks.Done()
+		return ctx.Err()
+	}
+}
+
+// idle blocks until the tasks submitted are done or ctx is done.
+func (p *pool) idle(ctx context.Context) error {
+	return waitAll(ctx, &p.tasks)
+}
+
+// close stops accepting tasks and waits for the running ones to finish.
+func (p *pool) close() {
+	close(p.c)
+	p.wg.Wait()
+}
+
+// closeWithin is like close but gives up waiting after timeout. It reports whether
+// all the running tasks finished.
+func (p *pool) closeWithin(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		p.close()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// waitAll blocks until wg's counter drops to zero or ctx is done.
+func waitAll(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// submit runs task on workers once the concurrency controller lets it talk to SAFER.
+// The task is dropped if ctx is done by then, e.g. so that a sweep keeps its dot
+// number pending in the checkpoint. It blocks until a worker picks the task up or ctx
+// is done.
+func (c *Crawler) submit(ctx context.Context, workers *pool, task func()) error {
+	return workers.submit(ctx, func() {
+		c.Concurrency.acquire()
+		defer c.Concurrency.release()
+		if ctx.Err() != nil {
+			return
+		}
+		task()
+	})
+}
+
+// finish waits for the tasks submitted to workers and for the sink to store what
+// they fetched. If ctx is done, the tasks in flight are only given shutdownGrace to
+// finish, then interrupted is called, unless it is nil, to save the progress of the
+// crawl and ctx's error is returned, or interrupted's if it failed.
+func (c *Crawler) finish(ctx context.Context, workers *pool, interrupted func() error) error {
+	dbCtx := context.Background()
+	if ctx.Err() == nil {
+		workers.close()
+		c.flush(dbCtx)
+		return nil
+	}
+	log.Info("interrupted, waiting for requests in flight")
+	if !workers.closeWithin(shutdownGrace) {
+		log.WithField("grace", shutdownGrace).Warn("requests in flight did not finish in time")
+	}
+	c.flush(dbCtx)
+	if interrupted != nil {
+		if err := interrupted(); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,185 @@
+package crawler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+// RefreshOrder selects which timestamp decides how stale a stored carrier is.
+type RefreshOrder string
+
+const (
+	// RefreshByCreatedAt refreshes the carriers crawled longest ago first.
+	RefreshByCreatedAt RefreshOrder = "created_at"
+	// RefreshByLatestUpdateTime refreshes the carriers with the oldest SAFER update
+	// date first. Carriers without an update date are skipped.
+	RefreshByLatestUpdateTime RefreshOrder = "latest_update_time"
+)
+
+const refreshPageSize = 1000
+
+// RefreshSafer re-fetches carriers already stored in the database, oldest first,
+// until every carrier older than maxAge has been refreshed or budget requests have
+// been made to SAFER, retries included. A budget of 0 means no limit.
+func (c *Crawler) RefreshSafer(ctx context.Context, order RefreshOrder, maxAge time.Duration, budget int) (err error) {
+	cc, dao := c.Concurrency, c.Dao
+	dbCtx := context.Background()
+
+	var next func(ctx context.Context) ([]int, error)
+	switch order {
+	case RefreshByCreatedAt, "":
+		next = createdAtPager(dao, int32(time.Now().Add(-maxAge).Unix()))
+	case RefreshByLatestUpdateTime:
+		next = latestUpdateTimePager(dao, time.Now().Add(-maxAge))
+	default:
+		return fmt.Errorf("unknown refresh order %q", order)
+	}
+
+	var requests *requestBudget
+	if budget > 0 {
+		requests = &requestBudget{limit: int64(budget)}
+		ctx = withRequestBudget(ctx, requests)
+	}
+
+	workers := newPool(cc.max)
+	carriers := 0
+	for !requests.spent() {
+		var dots []int
+		dots, err = next(ctx)
+		if err != nil || len(dots) == 0 {
+			break
+		}
+		for _, dot := range dots {
+			dotNumber := dot
+			err = c.submit(ctx, workers, func() {
+				s, err := c.getSaferSnapshot(ctx, dotNumber)
+				if err != nil {
+					switch {
+					case ctx.Err() != nil:
+					case errors.Is(err, errBudgetSpent):
+						// left for the next refresh
+					case errors.Is(err, safer.ErrCompanyNotFound):
+						log.WithField("dot", dotNumber).Info("carrier no longer on SAFER")
+					default:
+						c.recordFailure(dbCtx, dotNumber, fetchStage(err), err, time.Now())
+					}
+					return
+				}
+				c.write(dbCtx, dotNumber, s, func(err error) {
+					if err != nil {
+						c.recordFailure(dbCtx, dotNumber, stageWrite, err, time.Now())
+					}
+				})
+			})
+			// the carriers dispatched after the last request of the budget is taken
+			// are left for the next refresh
+			if err != nil || requests.spent() {
+				break
+			}
+			carriers++
+			if carriers%500 == 0 {
+				log.WithFields(log.Fields{"carriers": carriers, "concurrency": cc.Current(), "target": cc.Target()}).Info("refreshing")
+			}
+		}
+	}
+	if requests.spent() {
+		log.WithField("budget", budget).Info("refresh budget exhausted")
+	}
+
+	if err := c.finish(ctx, workers, nil); err != nil {
+		log.WithField("carriers", carriers).Info("refresh interrupted")
+		return err
+	}
+	log.WithField("carriers", carriers).Info("refresh finished")
+	return
+}
+
+// requestBudget caps the requests made to SAFER. A nil budget has no limit.
+type requestBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// take reserves a request, it reports false once the budget is spent
+func (b *requestBudget) take() bool {
+	return b == nil || b.used.Add(1) <= b.limit
+}
+
+// spent reports whether every request of the budget has been taken
+func (b *requestBudget) spent() bool {
+	return b != nil && b.used.Load() >= b.limit
+}
+
+var errBudgetSpent = errors.New("request budget spent")
+
+type requestBudgetKey struct{}
+
+// withRequestBudget returns a copy of ctx whose requests to SAFER are taken from b
+func withRequestBudget(ctx context.Context, b *requestBudget) context.Context {
+	return context.WithValue(ctx, requestBudgetKey{}, b)
+}
+
+// budgetFrom returns the request budget of ctx, nil if it has none
+func budgetFrom(ctx context.Context) *requestBudget {
+	b, _ := ctx.Value(requestBudgetKey{}).(*requestBudget)
+	return b
+}
+
+// createdAtPager pages through the carriers crawled before cutoff, keyed on
+// (created_at, dot_number) so that rewritten rows don't shift the pages.
+func createdAtPager(dao dao.Dao, cutoff int32) func(ctx context.Context) ([]int, error) {
+	var lastCreatedAt, lastDot int32
+	return func(ctx context.Context) ([]int, error) {
+		rows, err := dao.Queries.ListSnapshotsByCreatedAt(ctx, carrierleads.ListSnapshotsByCreatedAtParams{
+			CreatedAt:   cutoff,
+			CreatedAt_2: lastCreatedAt,
+			CreatedAt_3: lastCreatedAt,
+			DotNumber:   lastDot,
+			Limit:       refreshPageSize,
+		})
+		if err != nil || len(rows) == 0 {
+			return nil, err
+		}
+		dots := make([]int, len(rows))
+		for i, row := range rows {
+			dots[i] = int(row.DotNumber)
+		}
+		last := rows[len(rows)-1]
+		lastCreatedAt, lastDot = last.CreatedAt, last.DotNumber
+		return dots, nil
+	}
+}
+
+// latestUpdateTimePager pages through the carriers last updated on SAFER before
+// cutoff, keyed on (latest_update_time, dot_number).
+func latestUpdateTimePager(dao dao.Dao, cutoff time.Time) func(ctx context.Context) ([]int, error) {
+	last := sql.NullTime{Valid: true}
+	var lastDot int32
+	return func(ctx context.Context) ([]int, error) {
+		rows, err := dao.Queries.ListSnapshotsByLatestUpdateTime(ctx, carrierleads.ListSnapshotsByLatestUpdateTimeParams{
+			LatestUpdateTime:   sql.NullTime{Time: cutoff, Valid: true},
+			LatestUpdateTime_2: last,
+			LatestUpdateTime_3: last,
+			DotNumber:          lastDot,
+			Limit:              refreshPageSize,
+		})
+		if err != nil || len(rows) == 0 {
+			return nil, err
+		}
+		dots := make([]int, len(rows))
+		for i, row := range rows {
+			dots[i] = int(row.DotNumber)
+		}
+		last, lastDot = rows[len(rows)-1].LatestUpdateTime, rows[len(rows)-1].DotNumber
+		return dots, nil
+	}
+}
@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/dao/daotest"
+)
+
+// storedSnapshots answers ListSnapshotsByCreatedAt from the creation times of the
+// stored carriers by dot number
+func storedSnapshots(db *daotest.DB, createdAt map[int]time.Time) {
+	db.Handle("ListSnapshotsByCreatedAt", func(args []driver.Value) (*daotest.Rows, error) {
+		cutoff, lastCreatedAt, lastDot, limit := daotest.Int(args[0]), daotest.Int(args[1]), daotest.Int(args[3]), daotest.Int(args[4])
+		var rows []interface{}
+		for dot, at := range createdAt {
+			at := int(at.Unix())
+			if at < cutoff && (at > lastCreatedAt || at == lastCreatedAt && dot > lastDot) {
+				rows = append(rows, carrierleads.ListSnapshotsByCreatedAtRow{DotNumber: int32(dot), CreatedAt: int32(at)})
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			a, b := rows[i].(carrierleads.ListSnapshotsByCreatedAtRow), rows[j].(carrierleads.ListSnapshotsByCreatedAtRow)
+			return a.CreatedAt < b.CreatedAt || a.CreatedAt == b.CreatedAt && a.DotNumber < b.DotNumber
+		})
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+		return daotest.StructRows(rows...), nil
+	})
+}
+
+func TestCrawler_RefreshSafer(t *testing.T) {
+	defer func(b []time.Duration) { retryBackoffs = b }(retryBackoffs)
+	retryBackoffs = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+
+	now := time.Now()
+	days := func(n int) time.Time { return now.Add(-time.Duration(n) * 24 * time.Hour) }
+	stored := map[int]time.Time{
+		1: days(40),
+		2: days(60),
+		3: days(1), // fresh, never refreshed
+		4: days(50),
+		5: days(45),
+		6: days(31),
+	}
+
+	tests := []struct {
+		name    string
+		budget  int
+		flaky   map[int]int
+		lookups []int
+		written []int
+	}{
+		{
+			name:    "no budget",
+			lookups: []int{2, 4, 5, 1, 6},
+			written: []int{1, 2, 4, 5, 6},
+		},
+		{
+			name:    "budget",
+			budget:  3,
+			lookups: []int{2, 4, 5},
+			written: []int{2, 4, 5},
+		},
+		{
+			name:    "retries count against the budget",
+			budget:  4,
+			flaky:   map[int]int{4: 2},
+			lookups: []int{2, 4, 4, 4},
+			written: []int{2, 4},
+		},
+		{
+			name:    "budget spent by retries",
+			budget:  2,
+			flaky:   map[int]int{4: 2},
+			lookups: []int{2, 4},
+			written: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeSAFER()
+			for dot := range stored {
+				f.carrier(dot)
+			}
+			for dot, n := range tt.flaky {
+				f.flaky[dotKey(dot)] = n
+			}
+			db, d := daotest.New()
+			storedSnapshots(db, stored)
+			c, sink, stop := testCrawler(f, d)
+			defer stop()
+			// one worker, so that the carriers are looked up in order
+			c.Concurrency = NewConcurrencyController(1, 1)
+
+			if err := c.RefreshSafer(context.Background(), RefreshByCreatedAt, 30*24*time.Hour, tt.budget); err != nil {
+				t.Fatal(err)
+			}
+			var lookups []string
+			for _, dot := range tt.lookups {
+				lookups = append(lookups, dotKey(dot))
+			}
+			if !reflect.DeepEqual(f.lookups, lookups) {
+				t.Errorf("looked up %v, want %v", f.lookups, lookups)
+			}
+			if got := sink.dots(); !reflect.DeepEqual(got, tt.written) {
+				t.Errorf("refreshed %v, want %v", got, tt.written)
+			}
+			if failures := db.Calls("RecordCrawlFailure"); len(failures) > 0 {
+				t.Errorf("recorded failures %v", failures)
+			}
+		})
+	}
+}
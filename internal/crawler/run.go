@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/lib/safer"
+)
+
+// exit reasons stored for a crawl run that didn't fail
+const (
+	exitCompleted   = "completed"
+	exitInterrupted = "interrupted"
+)
+
+// maxExitReason bounds the error message stored as the exit reason of a failed run
+const maxExitReason = 255
+
+// RunStats counts what a crawl run did. FirstDot and LastDot are the lowest and
+// highest dot numbers looked up or stored, 0 if there were none.
+type RunStats struct {
+	FirstDot, LastDot int
+	Found             int
+	NotFound          int
+	Failed            int
+	Written           int
+	Requests          int
+	Retries           int
+}
+
+// runTally accumulates the RunStats of the current run. Like the Prometheus metrics
+// it is process wide, a process crawls one run at a time.
+type runTally struct {
+	mu    sync.Mutex
+	stats RunStats
+}
+
+var tally runTally
+
+func (t *runTally) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats = RunStats{}
+}
+
+func (t *runTally) get() RunStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// cover widens the dot range of the run to include dot
+func (t *runTally) cover(dot int) {
+	if dot <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stats.FirstDot == 0 || dot < t.stats.FirstDot {
+		t.stats.FirstDot = dot
+	}
+	if dot > t.stats.LastDot {
+		t.stats.LastDot = dot
+	}
+}
+
+// lookup counts the outcome of looking up a carrier, dot is 0 if it was looked up
+// by docket number
+func (t *runTally) lookup(dot int, err error) {
+	t.cover(dot)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case err == nil || errors.Is(err, safer.ErrRecordInactive):
+		t.stats.Found++
+	case errors.Is(err, safer.ErrCompanyNotFound):
+		t.stats.NotFound++
+	}
+}
+
+func (t *runTally) request(retried bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Requests++
+	if retried {
+		t.stats.Retries++
+	}
+}
+
+func (t *runTally) failure() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Failed++
+}
+
+func (t *runTally) write(dot int, err error) {
+	if err != nil {
+		return
+	}
+	t.cover(dot)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Written++
+}
+
+// Run is a crawl recorded in the crawl_runs table
+type Run struct {
+	ID int64
+	c  *Crawler
+}
+
+// StartRun records the start of a crawl in mode and resets the run statistics.
+// Without a database the run isn't recorded and its ID is 0.
+func (c *Crawler) StartRun(ctx context.Context, mode string) (*Run, error) {
+	tally.reset()
+	if !hasDatabase(c.Dao) {
+		return &Run{c: c}, nil
+	}
+	res, err := c.Dao.Queries.CreateCrawlRun(ctx, carrierleads.CreateCrawlRunParams{
+		Mode:      mode,
+		StartedAt: int32(time.Now().Unix()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &Run{ID: id, c: c}, nil
+}
+
+// Finish records the end of the run along with its statistics. crawlErr is the
+// error the crawl returned, it decides the exit reason.
+func (r *Run) Finish(ctx context.Context, crawlErr error) error {
+	if r.ID == 0 {
+		return nil
+	}
+	stats := tally.get()
+	return r.c.Dao.Queries.FinishCrawlRun(ctx, carrierleads.FinishCrawlRunParams{
+		EndedAt:    int32(time.Now().Unix()),
+		FirstDot:   int32(stats.FirstDot),
+		LastDot:    int32(stats.LastDot),
+		Found:      int32(stats.Found),
+		NotFound:   int32(stats.NotFound),
+		Failed:     int32(stats.Failed),
+		Written:    int32(stats.Written),
+		Requests:   int32(stats.Requests),
+		Retries:    int32(stats.Retries),
+		ExitReason: exitReason(crawlErr),
+		ID:         r.ID,
+	})
+}
+
+func exitReason(err error) string {
+	switch {
+	case err == nil:
+		return exitCompleted
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	}
+	reason := err.Error()
+	if len(reason) > maxExitReason {
+		reason = reason[:maxExitReason]
+	}
+	return reason
+}
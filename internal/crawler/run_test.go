@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"carrierleads.com/internal/lib/safer"
+)
+
+func Test_runTally(t *testing.T) {
+	var tl runTally
+	tl.lookup(120, nil)
+	tl.lookup(100, &safer.RecordInactiveError{})
+	tl.lookup(140, safer.ErrCompanyNotFound)
+	tl.lookup(0, nil)
+	tl.lookup(110, &safer.StatusError{StatusCode: 503})
+	tl.request(false)
+	tl.request(true)
+	tl.failure()
+	tl.write(150, nil)
+	tl.write(90, errors.New("deadlock"))
+
+	want := RunStats{FirstDot: 100, LastDot: 150, Found: 3, NotFound: 1, Failed: 1, Written: 1, Requests: 2, Retries: 1}
+	if got := tl.get(); got != want {
+		t.Errorf("get() = %+v, want %+v", got, want)
+	}
+	tl.reset()
+	if got := tl.get(); got != (RunStats{}) {
+		t.Errorf("get() after reset = %+v, want zero", got)
+	}
+}
+
+func Test_exitReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: exitCompleted},
+		{err: fmt.Errorf("sweep: %w", context.Canceled), want: exitInterrupted},
+		{err: errors.New("connection refused"), want: "connection refused"},
+		{err: errors.New(strings.Repeat("x", 300)), want: strings.Repeat("x", maxExitReason)},
+	}
+	for _, tt := range tests {
+		if got := exitReason(tt.err); got != tt.want {
+			t.Errorf("exitReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
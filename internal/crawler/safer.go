@@ -4,107 +4,353 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"carrierleads.com/internal/dao"
 	"carrierleads.com/internal/dao/carrierleads"
 	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
 )
 
-func CrawlSafer(DOTWatermark, bucketSize, numConnections int, dao dao.Dao) (err error) {
-	ctx := context.Background()
-	var wg sync.WaitGroup
-	c := make(chan func())
-	poll := func() {
-		defer wg.Done()
-		for {
-			t1, more := <-c
-			if more {
-				t1()
-			} else {
-				return
-			}
+// Crawler fetches carriers from SAFER and writes them to Sink, or to the database if
+// Sink is nil. The crawl state is kept in the database behind Dao. A zero Dao crawls
+// without a database: the full and list crawls then work without checkpoints, empty
+// ranges, failure queue and run history. The events of the carriers written to the
+// database are delivered by Notifier if it isn't nil.
+//
+// Once the ctx of a crawl is done no more work is dispatched, the requests in flight
+// are given shutdownGrace to finish and what they fetched is stored before the crawl
+// returns ctx's error.
+type Crawler struct {
+	Client      *safer.Client
+	Concurrency *ConcurrencyController
+	Dao         dao.Dao
+	Sink        Sink
+	Notifier    *Notifier
+}
+
+// hasDatabase reports whether d is connected to a database
+func hasDatabase(d dao.Dao) bool {
+	return d.Queries != nil
+}
+
+// CrawlSafer walks dot numbers upward until it is past the highest issued dot number.
+// Before sweeping, the upper bound is estimated by probing SAFER above the highest of
+// DOTWatermark, the stored carriers and the checkpoint. The sweep stops once the
+// bucketSize dot numbers past both the estimate and the highest carrier found have
+// been crawled. Progress is checkpointed in the database and the sweep resumes from
+// the last checkpoint unless restart is set.
+//
+// The dot numbers SAFER has no carrier for are stored as ranges and skipped by the
+// sweeps that start within emptyReprobe of the range being probed. The dot numbers
+// above the highest carrier found aren't stored, they may be issued at any time.
+//
+// An interrupted sweep saves its checkpoint before returning.
+func (c *Crawler) CrawlSafer(ctx context.Context, DOTWatermark, bucketSize int, emptyReprobe time.Duration, restart bool) (err error) {
+	cc, dao := c.Concurrency, c.Dao
+	// database writes don't use ctx so that an interrupted crawl still completes the
+	// writes in flight and records its checkpoint
+	dbCtx := context.Background()
+
+	if restart {
+		if err = deleteCheckpoint(ctx, dao, dotSweepCheckpoint); err != nil {
+			return
 		}
 	}
+	start, retry, err := loadCheckpoint(ctx, dao, dotSweepCheckpoint)
+	if err != nil {
+		return
+	}
+	if start > 0 {
+		log.WithFields(log.Fields{"watermark": start, "pending": len(retry)}).Info("resuming from checkpoint")
+	}
+	p := newProgress(start)
 
-	for i := 0; i < numConnections; i++ {
-		wg.Add(1)
-		go poll()
+	cutoff := time.Now().Add(-emptyReprobe)
+	skip, err := loadEmptyRanges(ctx, dao, cutoff)
+	if err != nil {
+		return
+	}
+	empty := newEmptyRanges()
+	// the highest carrier stored or found by the sweep, the dot numbers above it are
+	// empty only because they aren't issued yet
+	var found highWater
+	// saves the empty ranges below the watermark, they won't grow anymore unless the
+	// sweep is complete, and below the highest carrier found
+	saveEmpty := func(complete bool) {
+		watermark, _ := p.pending()
+		below := watermark
+		if complete {
+			below = watermark + 1
+		}
+		if f := found.get(); f < below {
+			below = f
+		}
+		if err := saveEmptyRanges(dbCtx, dao, empty.take(below), start, watermark, cutoff); err != nil {
+			log.WithError(err).Error("failed to save empty ranges")
+		}
 	}
 
-	// map to store number of failed safer fetch in dot range segmented by bucket size.
-	// this is used to detect the upperbound of available dotnumber
-	notFound := sync.Map{}
-	terminate := false
-	dot := 1
-	for {
-		if dot%500 == 0 {
-			log.Println("processing", dot)
+	from := start
+	if DOTWatermark > from {
+		from = DOTWatermark
+	}
+	known, err := lastKnownDot(ctx, dao)
+	if err != nil {
+		return
+	}
+	if known > from {
+		from = known
+	}
+	found.raise(known)
+	// ranges above the highest stored carrier may have been issued since they were
+	// probed
+	skip = rangesBelow(skip, known)
+	bound, err := discoverUpperBound(ctx, from, c.saferExists)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		log.WithError(err).Warn("failed to estimate the upper bound, sweeping past the highest carrier found")
+		bound = from
+	} else {
+		log.WithField("dot", bound).Info("estimated upper bound")
+	}
+	// the sweep ends bucketSize dot numbers past the highest of the estimate and the
+	// carriers found so far
+	var highest highWater
+	highest.raise(bound)
+
+	workers := newPool(cc.max)
+	// a dot number dropped once ctx is done is left in flight, so that the checkpoint
+	// keeps it pending
+	process := func(dotNumber int) {
+		s, err := c.getSaferSnapshot(ctx, int(dotNumber))
+		if err != nil && ctx.Err() != nil {
+			return
 		}
-		if terminate {
-			log.Println("upperbound reached, terminating")
-			break
+		if errors.Is(err, safer.ErrCompanyNotFound) {
+			empty.add(dotNumber)
+			p.finish(dotNumber, true)
+			return
 		}
-		dotNumber := dot
+		if err != nil {
+			c.recordFailure(dbCtx, dotNumber, fetchStage(err), err, time.Now())
+			p.finish(dotNumber, false)
+			return
+		}
+		highest.raise(dotNumber)
+		found.raise(dotNumber)
 
-		c <- func() {
-			s, err := getSaferSnapshot(int(dotNumber))
+		// the dot number stays pending in the checkpoint until it is stored
+		c.write(dbCtx, dotNumber, s, func(err error) {
 			if err != nil {
-				log.Print("failed to get snapshot ", dotNumber, err)
-
-				bucket := dotNumber / bucketSize
-				cnt, ok := notFound.Load(bucket)
-				if ok {
-					notFound.Store(bucket, cnt.(int)+1)
-					if cnt.(int)+1 == bucketSize && dotNumber > DOTWatermark {
-						terminate = true
-					}
-				} else {
-					notFound.Store(bucket, 1)
-				}
-				return
+				c.recordFailure(dbCtx, dotNumber, stageWrite, err, time.Now())
 			}
+			p.finish(dotNumber, err == nil)
+		})
+	}
 
-			err = writeToDB(s, dotNumber, ctx, dao)
-			if err != nil {
-				log.Printf("failed to write result for %d into db %v", dotNumber, err)
+	dispatch := func(dotNumber int) error {
+		p.start(dotNumber)
+		return c.submit(ctx, workers, func() { process(dotNumber) })
+	}
+
+	// retry the dot numbers left pending below the checkpoint, the ones above it
+	// are covered by the sweep
+	for _, dot := range retry {
+		if dot > start || dispatch(dot) != nil {
+			break
+		}
+	}
+
+	dot, skipped := start+1, 0
+	for ctx.Err() == nil {
+		if dot > highest.get()+bucketSize {
+			// the requests in flight may still find carriers that extend the sweep
+			if workers.idle(ctx) != nil || dot > highest.get()+bucketSize {
+				break
+			}
+			continue
+		}
+		for len(skip) > 0 && skip[0].end < dot {
+			skip = skip[1:]
+		}
+		if len(skip) > 0 && skip[0].start <= dot {
+			// recently probed and found empty
+			skipped += skip[0].end - dot + 1
+			for ; dot <= skip[0].end; dot++ {
+				p.start(dot)
+				p.finish(dot, true)
+			}
+			continue
+		}
+		if dot%500 == 0 {
+			log.WithFields(log.Fields{"dot": dot, "concurrency": cc.Current(), "target": cc.Target()}).Info("processing")
+			c.flush(dbCtx)
+			if err := saveCheckpoint(dbCtx, dao, dotSweepCheckpoint, p); err != nil {
+				log.WithError(err).Error("failed to save checkpoint")
 			}
+			saveEmpty(false)
 		}
+		if dispatch(dot) != nil {
+			break
+		}
+		cursor.WithLabelValues("dot").Set(float64(dot))
 		dot += 1
 	}
-	close(c)
-	wg.Wait()
+
+	err = c.finish(ctx, workers, func() error {
+		saveEmpty(false)
+		return c.checkpoint(dotSweepCheckpoint, p)
+	})
+	if err != nil {
+		return
+	}
+	log.WithFields(log.Fields{"dot": dot - 1, "skipped": skipped}).Info("upper bound reached, terminating")
+	saveEmpty(true)
+
+	// the sweep is complete, the next run starts over
+	err = deleteCheckpoint(dbCtx, dao, dotSweepCheckpoint)
 	return
 }
 
-func getSaferSnapshot(dotNumber int) (ret *safer.CompanySnapshot, err error) {
-	var backoffSchedule = []time.Duration{
-		1 * time.Second,
-		3 * time.Second,
-		10 * time.Second,
+// how long a single request to SAFER may take before it is abandoned
+const requestTimeout = 20 * time.Second
+
+func (c *Crawler) getSaferSnapshot(ctx context.Context, dotNumber int) (*safer.CompanySnapshot, error) {
+	s, err := fetchWithRetry(ctx, c.Concurrency, strconv.Itoa(dotNumber), c.Client.GetCompanyByDOTNumberContext)
+	tally.lookup(dotNumber, err)
+	if errors.Is(err, safer.ErrRecordInactive) {
+		return inactiveSnapshot(dotNumber, err), nil
 	}
+	return s, err
+}
 
-	for _, backoff := range backoffSchedule {
-		client := safer.NewClient()
-		ret, err = client.GetCompanyByDOTNumber(strconv.Itoa(dotNumber))
-		if err == nil {
-			return
+// retryBackoffs are the waits before the retries of a failed request
+var retryBackoffs = []time.Duration{
+	1 * time.Second,
+	3 * time.Second,
+	10 * time.Second,
+}
+
+// fetchWithRetry calls fetch with query until it succeeds, fails with an error that
+// won't change on retry or runs out of retries. The outcome of every request is
+// reported to cc. Every request is taken from the request budget of ctx, if it has
+// one, and none is made once it is spent.
+func fetchWithRetry[T any](ctx context.Context, cc *ConcurrencyController, query string, fetch func(context.Context, string) (T, error)) (ret T, err error) {
+	budget := budgetFrom(ctx)
+	for attempt, backoff := range retryBackoffs {
+		if !budget.take() {
+			var zero T
+			return zero, errBudgetSpent
 		}
-		if err.Error() == "company not found" {
+		// the request isn't tied to ctx so that an interrupted crawl lets it finish,
+		// it is bounded by requestTimeout instead. The wait for the rate limit isn't
+		// counted, a request throttled by the crawler itself doesn't time out.
+		reqCtx := safer.WithRequestTimeout(context.Background(), requestTimeout)
+		start := time.Now()
+		ret, err = fetch(reqCtx, query)
+		latency := time.Since(start)
+		cc.observe(latency, err)
+		observeRequest(latency, err)
+		tally.request(attempt > 0)
+		if !retryable(err) {
 			return
 		}
-		log.Printf("safer api error %d %+v \n", dotNumber, err)
-		log.Printf("retrying %d in %v", dotNumber, backoff)
-		time.Sleep(backoff)
+		retriesTotal.Inc()
+		// honor the delay requested by SAFER if it is longer than ours
+		var statusErr *safer.StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > backoff {
+			backoff = statusErr.RetryAfter
+		}
+		log.WithFields(failureFields(fetchStage(err), err)).WithFields(log.Fields{
+			"query":      query,
+			"attempt":    attempt + 1,
+			"latency_ms": latency.Milliseconds(),
+			"backoff":    backoff,
+		}).Warn("retrying request to SAFER")
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
 	}
 	return
 }
 
-func writeToDB(s *safer.CompanySnapshot, dotNumber int, ctx context.Context, dao dao.Dao) (err error) {
+// operating status stored for inactive records
+const inactiveStatus = "INACTIVE"
+
+// inactiveSnapshot builds the snapshot stored for a dot number whose record is inactive.
+// When SAFER doesn't say when the record went inactive the current date is used, the
+// database keeps the first date it was seen inactive.
+func inactiveSnapshot(dotNumber int, err error) *safer.CompanySnapshot {
+	s := &safer.CompanySnapshot{
+		DOTNumber:       strconv.Itoa(dotNumber),
+		OperatingStatus: inactiveStatus,
+	}
+	var inactiveErr *safer.RecordInactiveError
+	if errors.As(err, &inactiveErr) {
+		s.InactiveDate = inactiveErr.InactiveDate
+	}
+	if s.InactiveDate == nil {
+		now := time.Now()
+		s.InactiveDate = &now
+	}
+	return s
+}
+
+// retryable reports whether a failed request to SAFER is worth retrying
+func retryable(err error) bool {
+	var statusErr *safer.StatusError
+	var transportErr *safer.TransportError
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &statusErr):
+		return statusErr.Temporary()
+	case errors.As(err, &transportErr):
+		return true
+	default:
+		// not found, inactive and parse errors won't change on retry
+		return false
+	}
+}
+
+// write hands s, the snapshot of dotNumber, to the crawler's sink and calls done once
+// it is stored or failed to be. A sink writing in batches calls done when the batch is
+// flushed, after write returned.
+func (c *Crawler) write(ctx context.Context, dotNumber int, s *safer.CompanySnapshot, done func(err error)) {
+	sink := c.Sink
+	if sink == nil {
+		sink = MySQLSink{Dao: c.Dao, Notifier: c.Notifier}
+	}
+	start := time.Now()
+	writeAsync(ctx, sink, s, func(err error) {
+		latency := time.Since(start)
+		observeWrite(latency, err)
+		tally.write(dotNumber, err)
+		if err == nil {
+			log.WithFields(log.Fields{"dot": dotNumber, "latency_ms": latency.Milliseconds()}).Debug("stored carrier")
+		}
+		done(err)
+	})
+}
+
+// flush waits for the snapshots handed to the sink to be stored
+func (c *Crawler) flush(ctx context.Context) {
+	if a, ok := c.Sink.(AsyncSink); ok {
+		a.Flush(ctx)
+	}
+}
+
+// snapshotParams builds the row stored for s
+func snapshotParams(s *safer.CompanySnapshot) (params carrierleads.CreateSaferSnapshotParams, err error) {
 	buildNullTime := func(in *time.Time) sql.NullTime {
 		if in == nil {
 			return sql.NullTime{Valid: false}
@@ -125,11 +371,10 @@ func writeToDB(s *safer.CompanySnapshot, dotNumber int, ctx context.Context, dao
 
 	newDotNumber, err := strconv.Atoi(s.DOTNumber)
 	if err != nil {
-		log.Print("failed to convert dotnumber", s, dotNumber)
 		return
 	}
 
-	params := carrierleads.CreateSaferSnapshotParams{
+	params = carrierleads.CreateSaferSnapshotParams{
 		EntityType:             s.EntityType,
 		OperatingStatus:        s.OperatingStatus,
 		OosDate:                buildNullTime(s.OutOfServiceDate),
@@ -161,6 +406,7 @@ func writeToDB(s *safer.CompanySnapshot, dotNumber int, ctx context.Context, dao
 		SafetyRatingType:       s.Safety.Type,
 		LatestUpdateTime:       buildNullTime(s.LatestUpdateDate),
 		CreatedAt:              int32(time.Now().Unix()),
+		InactiveDate:           buildNullTime(s.InactiveDate),
 	}
 
 	for _, oc := range s.OperationClassification {
@@ -264,6 +510,5 @@ func writeToDB(s *safer.CompanySnapshot, dotNumber int, ctx context.Context, dao
 			params.CcOther = cc
 		}
 	}
-	_, err = dao.Queries.CreateSaferSnapshot(ctx, params)
 	return
 }
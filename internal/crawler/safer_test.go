@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/dao/daotest"
+)
+
+// savedCheckpoint returns the last checkpoint saved in db
+func savedCheckpoint(t *testing.T, db *daotest.DB) (watermark int, pending []int) {
+	t.Helper()
+	saves := db.Calls("SaveCrawlCheckpoint")
+	if len(saves) == 0 {
+		t.Fatal("no checkpoint saved")
+	}
+	last := saves[len(saves)-1]
+	if err := json.Unmarshal(last[2].([]byte), &pending); err != nil {
+		t.Fatalf("pending %s: %v", last[2], err)
+	}
+	return daotest.Int(last[1]), pending
+}
+
+// storedCheckpoint answers GetCrawlCheckpoint with the checkpoint of a sweep saved
+// at watermark with pending left
+func storedCheckpoint(t *testing.T, db *daotest.DB, name string, watermark int, pending []int) {
+	t.Helper()
+	b, err := json.Marshal(pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Handle("GetCrawlCheckpoint", func(args []driver.Value) (*daotest.Rows, error) {
+		if args[0] != name {
+			return nil, nil
+		}
+		return daotest.StructRows(carrierleads.CrawlCheckpoint{Name: name, Watermark: int32(watermark), Pending: b}), nil
+	})
+}
+
+func TestCrawler_CrawlSafer_interrupted(t *testing.T) {
+	f := newFakeSAFER()
+	for dot := 1; dot <= 30; dot++ {
+		f.carrier(dot)
+	}
+	f.blocked[dotKey(5)] = true
+	db, d := daotest.New()
+	c, sink, stop := testCrawler(f, d)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-f.started
+		// interrupted while dot number 5 is in flight, it is released once the crawl
+		// waits for it
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		close(f.unblock)
+	}()
+	if err := c.CrawlSafer(ctx, 0, 10, time.Hour, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CrawlSafer() error = %v, want %v", err, context.Canceled)
+	}
+
+	written := map[int]bool{}
+	for _, dot := range sink.dots() {
+		written[dot] = true
+	}
+	if !written[5] {
+		t.Errorf("the carrier in flight when interrupted wasn't written, written %v", sink.dots())
+	}
+	watermark, pending := savedCheckpoint(t, db)
+	isPending := map[int]bool{}
+	for _, dot := range pending {
+		isPending[dot] = true
+	}
+	if isPending[5] || watermark < 5 {
+		t.Errorf("checkpoint at %d pending %v, want dot number 5 done", watermark, pending)
+	}
+	// every carrier below the watermark is written or left to retry
+	for dot := 1; dot <= watermark; dot++ {
+		if !written[dot] && !isPending[dot] {
+			t.Errorf("dot number %d below the watermark %d neither written nor pending", dot, watermark)
+		}
+	}
+	if len(db.Calls("DeleteCrawlCheckpoint")) != 0 {
+		t.Error("interrupted sweep deleted its checkpoint")
+	}
+}
+
+func TestCrawler_CrawlSafer_resume(t *testing.T) {
+	f := newFakeSAFER()
+	for dot := 1; dot <= 30; dot++ {
+		f.carrier(dot)
+	}
+	db, d := daotest.New()
+	c, sink, stop := testCrawler(f, d)
+	defer stop()
+
+	// a sweep saved at dot number 20, with 5 and 12 left to retry
+	p := newProgress(0)
+	for dot := 1; dot <= 20; dot++ {
+		p.start(dot)
+		p.finish(dot, dot != 5 && dot != 12)
+	}
+	if err := saveCheckpoint(context.Background(), d, dotSweepCheckpoint, p); err != nil {
+		t.Fatal(err)
+	}
+	watermark, pending := savedCheckpoint(t, db)
+	storedCheckpoint(t, db, dotSweepCheckpoint, watermark, pending)
+
+	if err := c.CrawlSafer(context.Background(), 0, 10, time.Hour, false); err != nil {
+		t.Fatalf("CrawlSafer() error = %v", err)
+	}
+	for dot := 1; dot <= 20; dot++ {
+		want := 0
+		if dot == 5 || dot == 12 {
+			want = 1
+		}
+		if n := f.count(dotKey(dot)); n != want {
+			t.Errorf("dot number %d below the watermark looked up %d times, want %d", dot, n, want)
+		}
+	}
+	written := map[int]bool{}
+	for _, dot := range sink.dots() {
+		written[dot] = true
+	}
+	for dot := 1; dot <= 30; dot++ {
+		if want := dot == 5 || dot == 12 || dot > 20; written[dot] != want {
+			t.Errorf("dot number %d written %v, want %v", dot, written[dot], want)
+		}
+	}
+	if len(db.Calls("DeleteCrawlCheckpoint")) != 1 {
+		t.Error("the completed sweep didn't delete its checkpoint")
+	}
+}
@@ -0,0 +1,149 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+// SearchKeywords returns keywords followed by every combination of prefixLength
+// letters, e.g. AA to ZZ for a prefixLength of 2
+func SearchKeywords(keywords []string, prefixLength int) []string {
+	ret := append([]string{}, keywords...)
+	prefixes := []string{""}
+	for i := 0; i < prefixLength; i++ {
+		var longer []string
+		for _, prefix := range prefixes {
+			for letter := 'A'; letter <= 'Z'; letter++ {
+				longer = append(longer, prefix+string(letter))
+			}
+		}
+		prefixes = longer
+	}
+	if prefixLength > 0 {
+		ret = append(ret, prefixes...)
+	}
+	return ret
+}
+
+// DiscoverByName searches SAFER for the carrier names matching each keyword, then
+// fetches and stores the carriers found that aren't in the database yet. It finds
+// carriers above the last swept dot number or in gaps of the sweep without walking
+// every dot number.
+func (c *Crawler) DiscoverByName(ctx context.Context, keywords []string) (err error) {
+	cc, dao := c.Concurrency, c.Dao
+	dbCtx := context.Background()
+
+	workers := newPool(cc.max)
+	dispatch := func(task func()) error {
+		return c.submit(ctx, workers, task)
+	}
+
+	var mu sync.Mutex
+	found := map[int]struct{}{}
+	for _, keyword := range keywords {
+		keyword := strings.TrimSpace(keyword)
+		if keyword == "" {
+			continue
+		}
+		err = dispatch(func() {
+			results, err := fetchWithRetry(ctx, cc, keyword, c.Client.SearchCompaniesByNameContext)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.WithField("keyword", keyword).WithFields(failureFields(fetchStage(err), err)).Warn("failed to search")
+				}
+				return
+			}
+			log.WithFields(log.Fields{"keyword": keyword, "carriers": len(results)}).Info("searched")
+			mu.Lock()
+			defer mu.Unlock()
+			for _, result := range results {
+				if dot, err := strconv.Atoi(strings.TrimSpace(result.DOTNumber)); err == nil && dot > 0 {
+					found[dot] = struct{}{}
+				}
+			}
+		})
+		if err != nil {
+			break
+		}
+	}
+	if ctx.Err() == nil {
+		err = workers.idle(ctx)
+	}
+
+	var dots []int32
+	if ctx.Err() == nil {
+		for dot := range found {
+			dots = append(dots, int32(dot))
+		}
+		log.WithField("carriers", len(dots)).Info("searches done")
+	}
+	// the carriers already stored are left out before they take a worker, the workers
+	// are for the requests to SAFER
+	if len(dots) > 0 {
+		if dots, err = unstoredDots(dbCtx, dao, dots); err != nil {
+			log.WithError(err).Error("failed to look up the stored carriers")
+		}
+	}
+
+	var stored atomic.Int64
+	for _, dot := range dots {
+		dotNumber := int(dot)
+		err = dispatch(func() {
+			s, err := c.getSaferSnapshot(ctx, dotNumber)
+			if err != nil {
+				if ctx.Err() == nil && !errors.Is(err, safer.ErrCompanyNotFound) {
+					c.recordFailure(dbCtx, dotNumber, fetchStage(err), err, time.Now())
+				}
+				return
+			}
+			c.write(dbCtx, dotNumber, s, func(err error) {
+				if err != nil {
+					c.recordFailure(dbCtx, dotNumber, stageWrite, err, time.Now())
+					return
+				}
+				stored.Add(1)
+			})
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	if err := c.finish(ctx, workers, nil); err != nil {
+		log.WithField("stored", stored.Load()).Info("discovery interrupted")
+		return err
+	}
+	log.WithField("stored", stored.Load()).Info("discovery finished")
+	return
+}
+
+// unstoredDots returns the dot numbers of dots that aren't stored in the database,
+// sorted
+func unstoredDots(ctx context.Context, dao dao.Dao, dots []int32) ([]int32, error) {
+	stored, err := dao.Queries.GetStoredDotNumbers(ctx, dots)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int32]struct{}, len(stored))
+	for _, dot := range stored {
+		known[dot] = struct{}{}
+	}
+	unstored := make([]int32, 0, len(dots)-len(stored))
+	for _, dot := range dots {
+		if _, ok := known[dot]; !ok {
+			unstored = append(unstored, dot)
+		}
+	}
+	sort.Slice(unstored, func(i, j int) bool { return unstored[i] < unstored[j] })
+	return unstored, nil
+}
@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"carrierleads.com/internal/dao/daotest"
+	"carrierleads.com/internal/lib/safer"
+)
+
+func TestSearchKeywords(t *testing.T) {
+	if got, want := SearchKeywords([]string{"TRUCKING"}, 0), []string{"TRUCKING"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchKeywords() = %v, want %v", got, want)
+	}
+
+	got := SearchKeywords([]string{"TRUCKING", "LOGISTICS"}, 2)
+	if len(got) != 2+26*26 {
+		t.Fatalf("SearchKeywords() returned %d keywords, want %d", len(got), 2+26*26)
+	}
+	if got[0] != "TRUCKING" || got[2] != "AA" || got[3] != "AB" || got[len(got)-1] != "ZZ" {
+		t.Errorf("SearchKeywords() = %v...%v", got[:4], got[len(got)-1])
+	}
+}
+
+func TestCrawler_DiscoverByName_multiWordKeyword(t *testing.T) {
+	var mu sync.Mutex
+	var searched []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		searched = append(searched, r.URL.Query().Get("searchstring"))
+		mu.Unlock()
+		w.Write([]byte("<html><body></body></html>"))
+	}))
+	defer srv.Close()
+
+	c := &Crawler{
+		Client:      safer.NewClient(safer.WithBaseURL(srv.URL)),
+		Concurrency: NewConcurrencyController(1, 1),
+	}
+	if err := c.DiscoverByName(context.Background(), []string{"FREIGHT LINES"}); err != nil {
+		t.Fatalf("DiscoverByName() error = %v", err)
+	}
+	if want := []string{"*FREIGHT LINES*"}; !reflect.DeepEqual(searched, want) {
+		t.Errorf("searched %q, want %q", searched, want)
+	}
+}
+
+func TestCrawler_DiscoverByName_skipsStoredCarriers(t *testing.T) {
+	f := newFakeSAFER()
+	f.carrier(1, 2, 3)
+	f.searches["ACME"] = []int{3, 1, 2}
+	db, d := daotest.New()
+	db.Handle("GetStoredDotNumber", func(args []driver.Value) (*daotest.Rows, error) {
+		return daotest.Value(int64(2)), nil
+	})
+	c, sink, stop := testCrawler(f, d)
+	defer stop()
+
+	if err := c.DiscoverByName(context.Background(), []string{"ACME"}); err != nil {
+		t.Fatalf("DiscoverByName() error = %v", err)
+	}
+	if got, want := f.looked(), []string{dotKey(1), dotKey(3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("looked up %v, want %v", got, want)
+	}
+	if got, want := sink.dots(), []int{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stored %v, want %v", got, want)
+	}
+	// the stored carriers are looked up in the database at once
+	if lookups := db.Calls("GetStoredDotNumber"); len(lookups) != 1 || len(lookups[0]) != 3 {
+		t.Errorf("looked up the stored carriers with %v, want a single query", lookups)
+	}
+}
@@ -0,0 +1,264 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
+)
+
+// Sink receives the snapshots of the carriers crawled. Write is called concurrently
+// by the workers.
+type Sink interface {
+	// Write stores s
+	Write(ctx context.Context, s *safer.CompanySnapshot) error
+	// Close flushes the snapshots written. The sink can't be written to afterwards.
+	Close() error
+}
+
+// AsyncSink is a Sink storing the snapshots in the background. The crawler writes to
+// it with WriteAsync, and only considers a snapshot stored once its done function
+// is called without error.
+type AsyncSink interface {
+	Sink
+	// WriteAsync queues s and calls done once s is stored or failed to be
+	WriteAsync(ctx context.Context, s *safer.CompanySnapshot, done func(err error))
+	// Flush stores the snapshots queued and returns once their done functions are
+	// called
+	Flush(ctx context.Context)
+}
+
+// writeAsync writes s to sink and calls done with the outcome, right away unless the
+// sink is an AsyncSink
+func writeAsync(ctx context.Context, sink Sink, s *safer.CompanySnapshot, done func(err error)) {
+	if a, ok := sink.(AsyncSink); ok {
+		a.WriteAsync(ctx, s, done)
+		return
+	}
+	done(sink.Write(ctx, s))
+}
+
+// MySQLSink stores the snapshots in the fmcsa_carrier_safer table, their versions in
+// fmcsa_carrier_safer_history and their events in carrier_events. The events stored
+// are delivered by Notifier if it isn't nil.
+type MySQLSink struct {
+	Dao      dao.Dao
+	Notifier *Notifier
+}
+
+func (m MySQLSink) Write(ctx context.Context, s *safer.CompanySnapshot) error {
+	w, err := snapshotWrite(s)
+	if err != nil {
+		return err
+	}
+	writes := []dao.SnapshotWrite{w}
+	if err = m.Dao.WriteSnapshots(ctx, writes, diffStored); err != nil {
+		return err
+	}
+	m.Notifier.notify(writes[0].Events)
+	return nil
+}
+
+func (m MySQLSink) Close() error {
+	return nil
+}
+
+type batchSink struct {
+	dao      dao.Dao
+	w        *dao.BatchWriter
+	notifier *Notifier
+}
+
+// NewBatchSink returns a sink storing the snapshots in the fmcsa_carrier_safer table
+// in batches of size carriers, flushed at least every interval. The events of the
+// carriers stored are delivered by the Notifier of c.
+func (c *Crawler) NewBatchSink(size int, interval time.Duration) AsyncSink {
+	return batchSink{c.Dao, c.Dao.NewBatchWriter(size, interval, diffStored), c.Notifier}
+}
+
+// Write queues s, its write is only logged if it fails
+func (b batchSink) Write(ctx context.Context, s *safer.CompanySnapshot) error {
+	return b.queue(ctx, s, func(err error) {
+		if err != nil {
+			log.WithField("dot", s.DOTNumber).WithFields(failureFields(stageWrite, err)).Error("failed to store carrier")
+		}
+	})
+}
+
+func (b batchSink) WriteAsync(ctx context.Context, s *safer.CompanySnapshot, done func(err error)) {
+	if err := b.queue(ctx, s, done); err != nil {
+		done(err)
+	}
+}
+
+func (b batchSink) queue(ctx context.Context, s *safer.CompanySnapshot, done func(err error)) error {
+	w, err := snapshotWrite(s)
+	if err != nil {
+		return err
+	}
+	b.w.Write(ctx, w, func(w dao.SnapshotWrite, err error) {
+		if err == nil {
+			b.notifier.notify(w.Events)
+		}
+		done(err)
+	})
+	return nil
+}
+
+func (b batchSink) Flush(ctx context.Context) {
+	b.w.Flush(ctx)
+}
+
+func (b batchSink) Close() error {
+	b.w.Close()
+	return nil
+}
+
+// JSONLSink writes the snapshots to a writer as JSON lines
+type JSONLSink struct {
+	mu  sync.Mutex
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLSink returns a sink writing to w. Closing the sink flushes it but leaves w
+// open.
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	bw := bufio.NewWriter(w)
+	return &JSONLSink{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (j *JSONLSink) Write(ctx context.Context, s *safer.CompanySnapshot) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.enc.Encode(s)
+}
+
+func (j *JSONLSink) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.w.Flush()
+}
+
+// columns written by the csv sink
+var csvHeader = []string{
+	"dot_number", "legal_name", "dba_name", "entity_type", "operating_status",
+	"physical_address", "mailing_address", "phone", "mc_mx_ff_numbers", "duns_number",
+	"state_carrier_id", "power_units", "drivers", "carrier_operation",
+	"operation_classification", "cargo_carried", "safety_rating", "safety_rating_date",
+	"mcs_150_form_date", "out_of_service_date", "inactive_date", "latest_update_date",
+}
+
+func csvRecord(s *safer.CompanySnapshot) []string {
+	date := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	return []string{
+		s.DOTNumber, s.LegalName, s.DBAName, s.EntityType, s.OperatingStatus,
+		s.PhysicalAddress, s.MailingAddress, s.Phone, strings.Join(s.MCMXFFNumbers, ";"), s.DUNSNumber,
+		s.StateCarrierID, strconv.Itoa(s.PowerUnits), strconv.Itoa(s.Drivers), strings.Join(s.CarrierOperation, ";"),
+		strings.Join(s.OperationClassification, ";"), strings.Join(s.CargoCarried, ";"), s.Safety.Rating, date(s.Safety.RatingDate),
+		date(s.MCS150FormDate), date(s.OutOfServiceDate), date(s.InactiveDate), date(s.LatestUpdateDate),
+	}
+}
+
+// CSVSink writes the snapshots to a writer as CSV, one carrier per row. Lists are
+// joined with semicolons and the inspections and crashes are left out.
+type CSVSink struct {
+	mu sync.Mutex
+	w  *csv.Writer
+}
+
+// NewCSVSink returns a sink writing to w, starting with the header row. Closing the
+// sink flushes it but leaves w open.
+func NewCSVSink(w io.Writer) (*CSVSink, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &CSVSink{w: cw}, nil
+}
+
+func (c *CSVSink) Write(ctx context.Context, s *safer.CompanySnapshot) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.w.Write(csvRecord(s))
+}
+
+func (c *CSVSink) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type multiSink []Sink
+
+// MultiSink returns a sink writing every snapshot to each of sinks. A failing sink
+// doesn't keep the snapshot from the others, the first error is returned.
+func MultiSink(sinks ...Sink) AsyncSink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Write(ctx context.Context, s *safer.CompanySnapshot) (err error) {
+	for _, sink := range m {
+		if werr := sink.Write(ctx, s); err == nil {
+			err = werr
+		}
+	}
+	return
+}
+
+// WriteAsync calls done once s is stored by every sink, with the first error
+func (m multiSink) WriteAsync(ctx context.Context, s *safer.CompanySnapshot, done func(err error)) {
+	if len(m) == 0 {
+		done(nil)
+		return
+	}
+	var mu sync.Mutex
+	var first error
+	remaining := len(m)
+	for _, sink := range m {
+		writeAsync(ctx, sink, s, func(err error) {
+			mu.Lock()
+			if first == nil {
+				first = err
+			}
+			remaining--
+			last, err := remaining == 0, first
+			mu.Unlock()
+			if last {
+				done(err)
+			}
+		})
+	}
+}
+
+func (m multiSink) Flush(ctx context.Context) {
+	for _, sink := range m {
+		if a, ok := sink.(AsyncSink); ok {
+			a.Flush(ctx)
+		}
+	}
+}
+
+func (m multiSink) Close() (err error) {
+	for _, sink := range m {
+		if cerr := sink.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return
+}
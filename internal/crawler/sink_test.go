@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/lib/safer"
+)
+
+func TestJSONLSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLSink(&buf)
+	ctx := context.Background()
+	want := []*safer.CompanySnapshot{
+		{DOTNumber: "1", LegalName: "ACME TRUCKING LLC", PowerUnits: 3},
+		{DOTNumber: "2", OperatingStatus: "INACTIVE"},
+	}
+	for _, s := range want {
+		if err := sink.Write(ctx, s); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Error("Write() should buffer until Close()")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	for _, w := range want {
+		var got safer.CompanySnapshot
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if !reflect.DeepEqual(&got, w) {
+			t.Errorf("line = %+v, want %+v", got, w)
+		}
+	}
+}
+
+func TestCSVSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink, err := NewCSVSink(&buf)
+	if err != nil {
+		t.Fatalf("NewCSVSink() error = %v", err)
+	}
+	date := time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC)
+	err = sink.Write(context.Background(), &safer.CompanySnapshot{
+		DOTNumber:     "123",
+		LegalName:     "ACME, TRUCKING",
+		MCMXFFNumbers: []string{"MC-1", "MC-2"},
+		InactiveDate:  &date,
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err = sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if len(records) != 2 || !reflect.DeepEqual(records[0], csvHeader) {
+		t.Fatalf("records = %q, want the header and one row", records)
+	}
+	row := map[string]string{}
+	for i, column := range csvHeader {
+		row[column] = records[1][i]
+	}
+	if row["dot_number"] != "123" || row["legal_name"] != "ACME, TRUCKING" || row["mc_mx_ff_numbers"] != "MC-1;MC-2" || row["inactive_date"] != "2022-10-29" || row["power_units"] != "0" {
+		t.Errorf("row = %v", row)
+	}
+}
+
+type failingSink struct{ closed bool }
+
+func (f *failingSink) Write(ctx context.Context, s *safer.CompanySnapshot) error {
+	return errors.New("disk full")
+}
+
+func (f *failingSink) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestMultiSink(t *testing.T) {
+	var buf bytes.Buffer
+	failing := &failingSink{}
+	sink := MultiSink(failing, NewJSONLSink(&buf))
+
+	if err := sink.Write(context.Background(), &safer.CompanySnapshot{DOTNumber: "1"}); err == nil || err.Error() != "disk full" {
+		t.Errorf("Write() error = %v, want disk full", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !failing.closed {
+		t.Error("Close() didn't close every sink")
+	}
+	if !strings.Contains(buf.String(), `"dot_number":"1"`) {
+		t.Errorf("output = %q, want the snapshot despite the failing sink", buf.String())
+	}
+}
+
+// queueSink is an AsyncSink storing the snapshots when flushed
+type queueSink struct {
+	err    error
+	queued []func(err error)
+}
+
+func (q *queueSink) Write(ctx context.Context, s *safer.CompanySnapshot) error {
+	return nil
+}
+
+func (q *queueSink) WriteAsync(ctx context.Context, s *safer.CompanySnapshot, done func(err error)) {
+	q.queued = append(q.queued, done)
+}
+
+func (q *queueSink) Flush(ctx context.Context) {
+	for _, done := range q.queued {
+		done(q.err)
+	}
+	q.queued = nil
+}
+
+func (q *queueSink) Close() error {
+	return nil
+}
+
+func TestMultiSink_WriteAsync(t *testing.T) {
+	var buf bytes.Buffer
+	queue := &queueSink{err: errors.New("deadlock")}
+	sink := MultiSink(NewJSONLSink(&buf), queue)
+
+	var calls int
+	var got error
+	sink.WriteAsync(context.Background(), &safer.CompanySnapshot{DOTNumber: "1"}, func(err error) {
+		calls++
+		got = err
+	})
+	if calls != 0 {
+		t.Fatal("WriteAsync() called done before the queued write was flushed")
+	}
+	sink.Flush(context.Background())
+	if calls != 1 || got == nil || got.Error() != "deadlock" {
+		t.Errorf("done called %d times with %v, want once with deadlock", calls, got)
+	}
+}
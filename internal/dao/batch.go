@@ -0,0 +1,111 @@
+package dao
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BatchWriter accumulates the carriers written by the crawl workers and stores them
+// with WriteSnapshots once size carriers are pending or interval has passed. When a
+// batch fails its carriers are written one by one so that a single bad row doesn't
+// lose the others. The carriers are compared with the stored ones by diff, and the
+// outcome of every carrier is reported to the done function it was written with.
+//
+// The carriers pending when the process dies are lost, Close flushes them on shutdown.
+type BatchWriter struct {
+	dao  Dao
+	size int
+	diff Differ
+
+	mu      sync.Mutex
+	pending []pendingWrite
+	// held while a batch is written, so that Flush waits for a flush in progress
+	flushing sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type pendingWrite struct {
+	w    SnapshotWrite
+	done func(w SnapshotWrite, err error)
+}
+
+// NewBatchWriter returns a writer flushing every size carriers and every interval,
+// it must be closed once the carriers are written.
+func (d Dao) NewBatchWriter(size int, interval time.Duration, diff Differ) *BatchWriter {
+	b := &BatchWriter{
+		dao:  d,
+		size: size,
+		diff: diff,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(b.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.Flush(context.Background())
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+	return b
+}
+
+// Write queues w for the next batch. done is called with the outcome once the batch
+// is flushed, along with w as updated by the Differ.
+func (b *BatchWriter) Write(ctx context.Context, w SnapshotWrite, done func(w SnapshotWrite, err error)) {
+	b.mu.Lock()
+	b.pending = append(b.pending, pendingWrite{w, done})
+	full := len(b.pending) >= b.size
+	b.mu.Unlock()
+	if full {
+		b.Flush(ctx)
+	}
+}
+
+// Flush writes the pending carriers and returns once their done functions are called
+func (b *BatchWriter) Flush(ctx context.Context) {
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	writes := make([]SnapshotWrite, len(pending))
+	for i, p := range pending {
+		writes[i] = p.w
+	}
+	err := b.dao.WriteSnapshots(ctx, writes, b.diff)
+	if err == nil {
+		for i, p := range pending {
+			p.done(writes[i], nil)
+		}
+		return
+	}
+	log.WithError(err).WithField("rows", len(pending)).Warn("batch write failed, writing the rows one by one")
+	for _, p := range pending {
+		single := []SnapshotWrite{p.w}
+		err := b.dao.WriteSnapshots(ctx, single, b.diff)
+		p.done(single[0], err)
+	}
+}
+
+// Close stops the periodic flushes and writes the pending carriers
+func (b *BatchWriter) Close() {
+	close(b.stop)
+	<-b.done
+	b.Flush(context.Background())
+}
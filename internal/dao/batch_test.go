@@ -0,0 +1,171 @@
+package dao_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+	"carrierleads.com/internal/dao/daotest"
+)
+
+// outcomes records the outcome of every write by dot number
+type outcomes struct {
+	mu   sync.Mutex
+	errs map[int32][]error
+	// whether a transaction was committed when each outcome was reported
+	committed map[int32]bool
+	db        *daotest.DB
+}
+
+func newOutcomes(db *daotest.DB) *outcomes {
+	return &outcomes{errs: map[int32][]error{}, committed: map[int32]bool{}, db: db}
+}
+
+func (o *outcomes) done(w dao.SnapshotWrite, err error) {
+	committed := len(o.db.Calls("COMMIT")) > 0
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.errs[w.Params.DotNumber] = append(o.errs[w.Params.DotNumber], err)
+	o.committed[w.Params.DotNumber] = committed
+}
+
+func (o *outcomes) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.errs)
+}
+
+// check fails unless every dot number got exactly one outcome, failed for the ones in
+// failed
+func (o *outcomes) check(t *testing.T, dots []int32, failed ...int32) {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.errs) != len(dots) {
+		t.Errorf("got outcomes for %d carriers, want %d", len(o.errs), len(dots))
+	}
+	for _, dot := range dots {
+		errs := o.errs[dot]
+		if len(errs) != 1 {
+			t.Errorf("dot number %d got %d outcomes, want 1", dot, len(errs))
+			continue
+		}
+		wantFailed := false
+		for _, f := range failed {
+			wantFailed = wantFailed || f == dot
+		}
+		if (errs[0] != nil) != wantFailed {
+			t.Errorf("dot number %d outcome = %v, want failed %v", dot, errs[0], wantFailed)
+		}
+		if errs[0] == nil && !o.committed[dot] {
+			t.Errorf("dot number %d reported stored before the transaction was committed", dot)
+		}
+	}
+}
+
+func write(dot int32) dao.SnapshotWrite {
+	return dao.SnapshotWrite{Params: carrierleads.CreateSaferSnapshotParams{DotNumber: dot, OperatingStatus: "AUTHORIZED"}}
+}
+
+func noDiff(*carrierleads.FmcsaCarrierSafer, *dao.SnapshotWrite) error {
+	return nil
+}
+
+// rowsWritten returns the number of carriers written by every CreateSaferSnapshot
+func rowsWritten(db *daotest.DB) []int {
+	fields := reflect.TypeOf(carrierleads.CreateSaferSnapshotParams{}).NumField()
+	var rows []int
+	for _, args := range db.Calls("CreateSaferSnapshot") {
+		rows = append(rows, len(args)/fields)
+	}
+	return rows
+}
+
+func TestBatchWriter_flushesWhenFull(t *testing.T) {
+	db, d := daotest.New()
+	o := newOutcomes(db)
+	b := d.NewBatchWriter(2, time.Hour, noDiff)
+	defer b.Close()
+
+	ctx := context.Background()
+	b.Write(ctx, write(1), o.done)
+	if o.count() != 0 || len(rowsWritten(db)) != 0 {
+		t.Fatalf("the first carrier was written before the batch was full")
+	}
+	b.Write(ctx, write(2), o.done)
+	o.check(t, []int32{1, 2})
+	if got, want := rowsWritten(db), []int{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows written = %v, want %v", got, want)
+	}
+}
+
+func TestBatchWriter_flushesEveryInterval(t *testing.T) {
+	db, d := daotest.New()
+	o := newOutcomes(db)
+	b := d.NewBatchWriter(100, 10*time.Millisecond, noDiff)
+	defer b.Close()
+
+	b.Write(context.Background(), write(1), o.done)
+	for deadline := time.Now().Add(time.Second); o.count() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	o.check(t, []int32{1})
+}
+
+func TestBatchWriter_Close(t *testing.T) {
+	db, d := daotest.New()
+	o := newOutcomes(db)
+	b := d.NewBatchWriter(100, time.Hour, noDiff)
+
+	ctx := context.Background()
+	for dot := int32(1); dot <= 3; dot++ {
+		b.Write(ctx, write(dot), o.done)
+	}
+	b.Close()
+	o.check(t, []int32{1, 2, 3})
+	if got, want := rowsWritten(db), []int{3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows written = %v, want %v", got, want)
+	}
+
+	// nothing is left to write twice
+	b.Flush(ctx)
+	o.check(t, []int32{1, 2, 3})
+}
+
+func TestBatchWriter_writesOneByOneWhenTheBatchFails(t *testing.T) {
+	db, d := daotest.New()
+	fields := reflect.TypeOf(carrierleads.CreateSaferSnapshotParams{}).NumField()
+	dotField := -1
+	for i := 0; i < fields; i++ {
+		if reflect.TypeOf(carrierleads.CreateSaferSnapshotParams{}).Field(i).Name == "DotNumber" {
+			dotField = i
+		}
+	}
+	// the row of dot number 2 is rejected, failing any statement it is part of
+	db.Handle("CreateSaferSnapshot", func(args []driver.Value) (*daotest.Rows, error) {
+		for row := 0; row < len(args)/fields; row++ {
+			if daotest.Int(args[row*fields+dotField]) == 2 {
+				return nil, errors.New("data too long for column")
+			}
+		}
+		return nil, nil
+	})
+	o := newOutcomes(db)
+	b := d.NewBatchWriter(3, time.Hour, noDiff)
+	defer b.Close()
+
+	ctx := context.Background()
+	for dot := int32(1); dot <= 3; dot++ {
+		b.Write(ctx, write(dot), o.done)
+	}
+	o.check(t, []int32{1, 2, 3}, 2)
+	if got, want := rowsWritten(db), []int{3, 1, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows written = %v, want the batch then every row alone %v", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+package carrierleads
+
+// This file isn't generated by sqlc, it extends the generated queries with the
+// multi-row variants sqlc can't express.
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+)
+
+// MaxBatchRows is the most rows written by a single multi-row statement, it keeps the
+// statements under the 65535 placeholders MySQL allows
+const MaxBatchRows = 500
+
+// CreateSaferSnapshots is CreateSaferSnapshot for several carriers in one statement
+func (q *Queries) CreateSaferSnapshots(ctx context.Context, args []CreateSaferSnapshotParams) error {
+	return execRows(ctx, q.db, createSaferSnapshot, args)
+}
+
+// MarkSnapshotsInactive is MarkSnapshotInactive for several carriers in one statement
+func (q *Queries) MarkSnapshotsInactive(ctx context.Context, args []MarkSnapshotInactiveParams) error {
+	return execRows(ctx, q.db, markSnapshotInactive, args)
+}
+
+// CreateCarrierEvents is CreateCarrierEvent for several events in one statement
+func (q *Queries) CreateCarrierEvents(ctx context.Context, args []CreateCarrierEventParams) error {
+	return execRows(ctx, q.db, createCarrierEvent, args)
+}
+
+// GetSaferSnapshots is GetSaferSnapshot for several carriers in one query, the
+// carriers that aren't stored are left out
+func (q *Queries) GetSaferSnapshots(ctx context.Context, dotNumbers []int32) ([]FmcsaCarrierSafer, error) {
+	var items []FmcsaCarrierSafer
+	err := queryDots(ctx, q.db, getSaferSnapshot, dotNumbers, func(rows *sql.Rows) error {
+		var i FmcsaCarrierSafer
+		// the columns are selected in the order of the fields
+		v := reflect.ValueOf(&i).Elem()
+		dest := make([]interface{}, v.NumField())
+		for f := range dest {
+			dest[f] = v.Field(f).Addr().Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		items = append(items, i)
+		return nil
+	})
+	return items, err
+}
+
+// GetStoredDotNumbers is GetStoredDotNumber for several carriers in one query, it
+// returns the dot numbers that are stored
+func (q *Queries) GetStoredDotNumbers(ctx context.Context, dotNumbers []int32) ([]int32, error) {
+	var items []int32
+	err := queryDots(ctx, q.db, getStoredDotNumber, dotNumbers, func(rows *sql.Rows) error {
+		var dot int32
+		if err := rows.Scan(&dot); err != nil {
+			return err
+		}
+		items = append(items, dot)
+		return nil
+	})
+	return items, err
+}
+
+// CloseSaferHistories is CloseSaferHistory for several carriers in one statement
+func (q *Queries) CloseSaferHistories(ctx context.Context, validTo int32, dotNumbers []int32) error {
+	return execDots(ctx, q.db, closeSaferHistory, validTo, dotNumbers)
+}
+
+// AppendSaferHistories is AppendSaferHistory for several carriers in one statement
+func (q *Queries) AppendSaferHistories(ctx context.Context, validFrom int32, dotNumbers []int32) error {
+	return execDots(ctx, q.db, appendSaferHistory, validFrom, dotNumbers)
+}
+
+// execDots runs query, whose placeholders are at then a single dot number, for every
+// dot number of dotNumbers, MaxBatchRows at a time
+func execDots(ctx context.Context, db DBTX, query string, at int32, dotNumbers []int32) error {
+	for len(dotNumbers) > 0 {
+		n := len(dotNumbers)
+		if n > MaxBatchRows {
+			n = MaxBatchRows
+		}
+		values := []interface{}{at}
+		for _, dot := range dotNumbers[:n] {
+			values = append(values, dot)
+		}
+		if _, err := db.ExecContext(ctx, dotList(query, n), values...); err != nil {
+			return err
+		}
+		dotNumbers = dotNumbers[n:]
+	}
+	return nil
+}
+
+// queryDots runs query, a single carrier lookup, for every dot number of dotNumbers,
+// MaxBatchRows at a time, and calls scan for every row of the results
+func queryDots(ctx context.Context, db DBTX, query string, dotNumbers []int32, scan func(*sql.Rows) error) error {
+	for len(dotNumbers) > 0 {
+		n := len(dotNumbers)
+		if n > MaxBatchRows {
+			n = MaxBatchRows
+		}
+		values := make([]interface{}, n)
+		for i, dot := range dotNumbers[:n] {
+			values[i] = dot
+		}
+		rows, err := db.QueryContext(ctx, dotList(query, n), values...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		dotNumbers = dotNumbers[n:]
+	}
+	return nil
+}
+
+// dotList matches query against n dot numbers instead of one
+func dotList(query string, n int) string {
+	return strings.Replace(query, "dot_number = ?", "dot_number IN ("+strings.TrimSuffix(strings.Repeat("?, ", n), ", ")+")", 1)
+}
+
+// execRows runs query, a single row insert, for every row of args, MaxBatchRows at a
+// time. The fields of the params structs are in the order of the placeholders.
+func execRows[T any](ctx context.Context, db DBTX, query string, args []T) error {
+	for len(args) > 0 {
+		n := len(args)
+		if n > MaxBatchRows {
+			n = MaxBatchRows
+		}
+		var values []interface{}
+		for _, arg := range args[:n] {
+			v := reflect.ValueOf(arg)
+			for i := 0; i < v.NumField(); i++ {
+				values = append(values, v.Field(i).Interface())
+			}
+		}
+		if _, err := db.ExecContext(ctx, multiRow(query, n), values...); err != nil {
+			return err
+		}
+		args = args[n:]
+	}
+	return nil
+}
+
+// multiRow repeats the tuple of placeholders following VALUES in query rows times
+func multiRow(query string, rows int) string {
+	start := strings.Index(query, "VALUES")
+	start += strings.Index(query[start:], "(")
+	end := start + strings.Index(query[start:], ")") + 1
+	tuple := query[start:end]
+	return query[:start] + strings.TrimSuffix(strings.Repeat(tuple+",\n\t", rows), ",\n\t") + query[end:]
+}
@@ -0,0 +1,102 @@
+package carrierleads
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_multiRow(t *testing.T) {
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", reflect.TypeOf(CreateSaferSnapshotParams{}).NumField()), ", ") + ")"
+	got := multiRow(createSaferSnapshot, 3)
+	if n := strings.Count(got, tuple); n != 3 {
+		t.Errorf("multiRow() has %d tuples, want 3:\n%s", n, got)
+	}
+	if n := strings.Count(got, "?"); n != 3*reflect.TypeOf(CreateSaferSnapshotParams{}).NumField() {
+		t.Errorf("multiRow() has %d placeholders", n)
+	}
+
+	got = multiRow(markSnapshotInactive, 2)
+	if !strings.Contains(got, tuple+",\n\t"+tuple+"\nON DUPLICATE KEY UPDATE") {
+		t.Errorf("multiRow() = %s", got)
+	}
+	if n := strings.Count(got, "?"); n != 2*reflect.TypeOf(MarkSnapshotInactiveParams{}).NumField() {
+		t.Errorf("multiRow() has %d placeholders", n)
+	}
+}
+
+type recordingDB struct {
+	DBTX
+	query string
+	args  []interface{}
+}
+
+func (r *recordingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r.query, r.args = query, args
+	return nil, nil
+}
+
+func TestQueries_CreateSaferSnapshots(t *testing.T) {
+	arg := CreateSaferSnapshotParams{
+		EntityType:      "CARRIER",
+		LegalName:       "ACME TRUCKING LLC",
+		DotNumber:       123,
+		PowerUnits:      3,
+		OcUsMail:        true,
+		CcOther:         "Tribal",
+		UsCrashSummary:  json.RawMessage(`{}`),
+		Mcs150FormDate:  sql.NullTime{Time: time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC), Valid: true},
+		CreatedAt:       1666000000,
+		OperatingStatus: "AUTHORIZED FOR Property",
+	}
+	single := &recordingDB{}
+	if _, err := New(single).CreateSaferSnapshot(context.Background(), arg); err != nil {
+		t.Fatal(err)
+	}
+	batch := &recordingDB{}
+	if err := New(batch).CreateSaferSnapshots(context.Background(), []CreateSaferSnapshotParams{arg, arg}); err != nil {
+		t.Fatal(err)
+	}
+	// the placeholders follow the order of the generated query
+	want := append(append([]interface{}{}, single.args...), single.args...)
+	if !reflect.DeepEqual(batch.args, want) {
+		t.Errorf("CreateSaferSnapshots() args = %v, want %v", batch.args, want)
+	}
+}
+
+func TestQueries_AppendSaferHistories(t *testing.T) {
+	db := &recordingDB{}
+	if err := New(db).AppendSaferHistories(context.Background(), 1666000000, []int32{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(db.query, "WHERE c.dot_number IN (?, ?, ?) AND NOT EXISTS") {
+		t.Errorf("AppendSaferHistories() query = %s", db.query)
+	}
+	if want := []interface{}{int32(1666000000), int32(1), int32(2), int32(3)}; !reflect.DeepEqual(db.args, want) {
+		t.Errorf("AppendSaferHistories() args = %v, want %v", db.args, want)
+	}
+
+	db = &recordingDB{}
+	if err := New(db).CloseSaferHistories(context.Background(), 1666000000, []int32{4}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(db.query, "WHERE dot_number IN (?) AND valid_to = 0") {
+		t.Errorf("CloseSaferHistories() query = %s", db.query)
+	}
+}
+
+func Test_dotList_getSaferSnapshot(t *testing.T) {
+	got := dotList(getSaferSnapshot, 3)
+	if !strings.Contains(got, "WHERE dot_number IN (?, ?, ?)") {
+		t.Errorf("dotList() = %s", got)
+	}
+	// GetSaferSnapshots scans the columns into the fields in order
+	columns := strings.Count(got[:strings.Index(got, " FROM ")], ",") + 1
+	if fields := reflect.TypeOf(FmcsaCarrierSafer{}).NumField(); columns != fields {
+		t.Errorf("query selects %d columns, FmcsaCarrierSafer has %d fields", columns, fields)
+	}
+}
@@ -9,6 +9,69 @@ import (
 	"encoding/json"
 )
 
+type CarrierDocket struct {
+	DocketNumber int32
+	DotNumber    int32
+	UpdatedAt    int32
+}
+
+type CarrierEvent struct {
+	ID         int64
+	DotNumber  int32
+	EventType  string
+	Details    json.RawMessage
+	OccurredAt int32
+}
+
+type CrawlCheckpoint struct {
+	Name      string
+	Watermark int32
+	Pending   json.RawMessage
+	UpdatedAt int32
+}
+
+type CrawlDocketFailure struct {
+	DocketNumber  int32
+	Stage         string
+	ErrorClass    string
+	LastError     string
+	Attempts      int32
+	LastAttemptAt int32
+	NextAttemptAt int32
+}
+
+type CrawlFailure struct {
+	DotNumber     int32
+	Stage         string
+	ErrorClass    string
+	LastError     string
+	Attempts      int32
+	LastAttemptAt int32
+	NextAttemptAt int32
+}
+
+type CrawlRun struct {
+	ID         int64
+	Mode       string
+	StartedAt  int32
+	EndedAt    int32
+	FirstDot   int32
+	LastDot    int32
+	Found      int32
+	NotFound   int32
+	Failed     int32
+	Written    int32
+	Requests   int32
+	Retries    int32
+	ExitReason string
+}
+
+type EmptyDotRange struct {
+	StartDot int32
+	EndDot   int32
+	ProbedAt int32
+}
+
 type FmcsaCarrierSafer struct {
 	EntityType                    string
 	OperatingStatus               string
@@ -83,4 +146,97 @@ type FmcsaCarrierSafer struct {
 	SafetyRatingType              string
 	LatestUpdateTime              sql.NullTime
 	CreatedAt                     int32
+	InactiveDate                  sql.NullTime
+}
+
+type FmcsaCarrierSaferHistory struct {
+	ID                            int64
+	EntityType                    string
+	OperatingStatus               string
+	OosDate                       sql.NullTime
+	LegalName                     string
+	DbaName                       string
+	Address                       string
+	Telephone                     string
+	MailingAddress                string
+	DotNumber                     int32
+	StateCarrierIDNumber          string
+	DocketNumber                  string
+	DunsNumber                    string
+	PowerUnits                    int32
+	Drivers                       int32
+	Mcs150FormDate                sql.NullTime
+	Mcs150MileageYear             string
+	CarrierOperation              string
+	OcAuthorizedForHire           bool
+	OcPrivatePassengerBusiness    bool
+	OcUsMail                      bool
+	OcLocalGovernment             bool
+	OcExemptForHire               bool
+	OcPrivatePassengerNonBusiness bool
+	OcFederalGovernment           bool
+	OcIndianTribe                 bool
+	OcPrivateProperty             bool
+	OcMigrant                     bool
+	OcStateGovernment             bool
+	OcOther                       string
+	CcGeneralFreight              bool
+	CcMotorVehicles               bool
+	CcBuildingMaterials           bool
+	CcFreshProduct                bool
+	CcPassengers                  bool
+	CcGrainFeedHay                bool
+	CcGarbageRefuseTrash          bool
+	CcCommoditiesDryBulk          bool
+	CcPaperProducts               bool
+	CcConstruction                bool
+	CcHouseholdGoods              bool
+	CcDriveAwayTowaway            bool
+	CcMobileHomes                 bool
+	CcLiquidsGases                bool
+	CcOilfieldEquipment           bool
+	CcCoalCoke                    bool
+	CcUsMail                      bool
+	CcRefrigeratedFood            bool
+	CcUtility                     bool
+	CcWaterwell                   bool
+	CcMetalSheetsCoilsRolls       bool
+	CcLogsPolesBeamsLumber        bool
+	CcMachineryLargeObjects       bool
+	CcIntermodalContainers        bool
+	CcLivestock                   bool
+	CcMeat                        bool
+	CcChemicals                   bool
+	CcBeverages                   bool
+	CcFarmSupplies                bool
+	CcOther                       string
+	UsInspectionVehicle           json.RawMessage
+	UsInspectionDriver            json.RawMessage
+	UsInspectionHazmat            json.RawMessage
+	UsInspectionIep               json.RawMessage
+	UsCrashSummary                json.RawMessage
+	CanInspectionVehicle          json.RawMessage
+	CanInspectionDriver           json.RawMessage
+	CanCrashSummary               json.RawMessage
+	SafetyRatingDate              sql.NullTime
+	SafetyRatingReviewDate        sql.NullTime
+	SafetyRating                  string
+	SafetyRatingType              string
+	LatestUpdateTime              sql.NullTime
+	CreatedAt                     int32
+	InactiveDate                  sql.NullTime
+	ValidFrom                     int32
+	ValidTo                       int32
+}
+
+type WebhookDelivery struct {
+	ID         int64
+	Url        string
+	EventType  string
+	DotNumber  int32
+	Attempts   int32
+	StatusCode int32
+	LastError  string
+	Delivered  bool
+	CreatedAt  int32
 }
@@ -11,10 +11,197 @@ import (
 	"encoding/json"
 )
 
+const appendSaferHistory = `-- name: AppendSaferHistory :exec
+INSERT INTO fmcsa_carrier_safer_history (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date, valid_from)
+SELECT c.entity_type, c.operating_status, c.oos_date, c.legal_name, c.dba_name, c.address, c.telephone, c.mailing_address, c.dot_number, c.state_carrier_id_number, c.docket_number, c.duns_number, c.power_units, c.drivers, c.mcs_150_form_date, c.mcs_150_mileage_year, c.carrier_operation, c.oc_authorized_for_hire, c.oc_private_passenger_business, c.oc_us_mail, c.oc_local_government, c.oc_exempt_for_hire, c.oc_private_passenger_non_business, c.oc_federal_government, c.oc_indian_tribe, c.oc_private_property, c.oc_migrant, c.oc_state_government, c.oc_other, c.cc_general_freight, c.cc_motor_vehicles, c.cc_building_materials, c.cc_fresh_product, c.cc_passengers, c.cc_grain_feed_hay, c.cc_garbage_refuse_trash, c.cc_commodities_dry_bulk, c.cc_paper_products, c.cc_construction, c.cc_household_goods, c.cc_drive_away_towaway, c.cc_mobile_homes, c.cc_liquids_gases, c.cc_oilfield_equipment, c.cc_coal_coke, c.cc_us_mail, c.cc_refrigerated_food, c.cc_utility, c.cc_waterwell, c.cc_metal_sheets_coils_rolls, c.cc_logs_poles_beams_lumber, c.cc_machinery_large_objects, c.cc_intermodal_containers, c.cc_livestock, c.cc_meat, c.cc_chemicals, c.cc_beverages, c.cc_farm_supplies, c.cc_other, c.us_inspection_vehicle, c.us_inspection_driver, c.us_inspection_hazmat, c.us_inspection_iep, c.us_crash_summary, c.can_inspection_vehicle, c.can_inspection_driver, c.can_crash_summary, c.safety_rating_date, c.safety_rating_review_date, c.safety_rating, c.safety_rating_type, c.latest_update_time, c.created_at, c.inactive_date, ?
+FROM fmcsa_carrier_safer c
+WHERE c.dot_number = ? AND NOT EXISTS (
+	SELECT 1 FROM fmcsa_carrier_safer_history h
+	WHERE h.dot_number = c.dot_number AND h.valid_to = 0
+)
+`
+
+type AppendSaferHistoryParams struct {
+	ValidFrom int32
+	DotNumber int32
+}
+
+func (q *Queries) AppendSaferHistory(ctx context.Context, arg AppendSaferHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, appendSaferHistory, arg.ValidFrom, arg.DotNumber)
+	return err
+}
+
+const closeSaferHistory = `-- name: CloseSaferHistory :exec
+UPDATE fmcsa_carrier_safer_history
+SET valid_to = ?
+WHERE dot_number = ? AND valid_to = 0
+`
+
+type CloseSaferHistoryParams struct {
+	ValidTo   int32
+	DotNumber int32
+}
+
+func (q *Queries) CloseSaferHistory(ctx context.Context, arg CloseSaferHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, closeSaferHistory, arg.ValidTo, arg.DotNumber)
+	return err
+}
+
+const countCrawlFailures = `-- name: CountCrawlFailures :many
+SELECT stage, error_class, COUNT(*) AS failures FROM crawl_failures
+GROUP BY stage, error_class
+ORDER BY failures DESC
+`
+
+type CountCrawlFailuresRow struct {
+	Stage      string
+	ErrorClass string
+	Failures   int64
+}
+
+func (q *Queries) CountCrawlFailures(ctx context.Context) ([]CountCrawlFailuresRow, error) {
+	rows, err := q.db.QueryContext(ctx, countCrawlFailures)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountCrawlFailuresRow
+	for rows.Next() {
+		var i CountCrawlFailuresRow
+		if err := rows.Scan(&i.Stage, &i.ErrorClass, &i.Failures); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countDocketFailures = `-- name: CountDocketFailures :many
+SELECT stage, error_class, COUNT(*) AS failures FROM crawl_docket_failures
+GROUP BY stage, error_class
+ORDER BY failures DESC
+`
+
+type CountDocketFailuresRow struct {
+	Stage      string
+	ErrorClass string
+	Failures   int64
+}
+
+func (q *Queries) CountDocketFailures(ctx context.Context) ([]CountDocketFailuresRow, error) {
+	rows, err := q.db.QueryContext(ctx, countDocketFailures)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountDocketFailuresRow
+	for rows.Next() {
+		var i CountDocketFailuresRow
+		if err := rows.Scan(&i.Stage, &i.ErrorClass, &i.Failures); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countEmptyDotRanges = `-- name: CountEmptyDotRanges :one
+SELECT COUNT(*) FROM empty_dot_ranges
+`
+
+func (q *Queries) CountEmptyDotRanges(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countEmptyDotRanges)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSnapshotsByOperatingStatus = `-- name: CountSnapshotsByOperatingStatus :many
+SELECT operating_status, COUNT(*) AS carriers FROM fmcsa_carrier_safer
+GROUP BY operating_status
+ORDER BY carriers DESC
+`
+
+type CountSnapshotsByOperatingStatusRow struct {
+	OperatingStatus string
+	Carriers        int64
+}
+
+func (q *Queries) CountSnapshotsByOperatingStatus(ctx context.Context) ([]CountSnapshotsByOperatingStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, countSnapshotsByOperatingStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSnapshotsByOperatingStatusRow
+	for rows.Next() {
+		var i CountSnapshotsByOperatingStatusRow
+		if err := rows.Scan(&i.OperatingStatus, &i.Carriers); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createCarrierEvent = `-- name: CreateCarrierEvent :exec
+INSERT INTO carrier_events (dot_number, event_type, details, occurred_at)
+VALUES (?, ?, ?, ?)
+`
+
+type CreateCarrierEventParams struct {
+	DotNumber  int32
+	EventType  string
+	Details    json.RawMessage
+	OccurredAt int32
+}
+
+func (q *Queries) CreateCarrierEvent(ctx context.Context, arg CreateCarrierEventParams) error {
+	_, err := q.db.ExecContext(ctx, createCarrierEvent,
+		arg.DotNumber,
+		arg.EventType,
+		arg.Details,
+		arg.OccurredAt,
+	)
+	return err
+}
+
+const createCrawlRun = `-- name: CreateCrawlRun :execresult
+INSERT INTO crawl_runs (mode, started_at)
+VALUES (?, ?)
+`
+
+type CreateCrawlRunParams struct {
+	Mode      string
+	StartedAt int32
+}
+
+func (q *Queries) CreateCrawlRun(ctx context.Context, arg CreateCrawlRunParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, createCrawlRun, arg.Mode, arg.StartedAt)
+}
+
 const createSaferSnapshot = `-- name: CreateSaferSnapshot :execresult
-REPLACE INTO fmcsa_carrier_safer (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at)
+REPLACE INTO fmcsa_carrier_safer (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date)
 VALUES
-	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateSaferSnapshotParams struct {
@@ -91,6 +278,7 @@ type CreateSaferSnapshotParams struct {
 	SafetyRatingType              string
 	LatestUpdateTime              sql.NullTime
 	CreatedAt                     int32
+	InactiveDate                  sql.NullTime
 }
 
 func (q *Queries) CreateSaferSnapshot(ctx context.Context, arg CreateSaferSnapshotParams) (sql.Result, error) {
@@ -168,5 +356,1279 @@ func (q *Queries) CreateSaferSnapshot(ctx context.Context, arg CreateSaferSnapsh
 		arg.SafetyRatingType,
 		arg.LatestUpdateTime,
 		arg.CreatedAt,
+		arg.InactiveDate,
 	)
 }
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (url, event_type, dot_number, attempts, status_code, last_error, delivered, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateWebhookDeliveryParams struct {
+	Url        string
+	EventType  string
+	DotNumber  int32
+	Attempts   int32
+	StatusCode int32
+	LastError  string
+	Delivered  bool
+	CreatedAt  int32
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, createWebhookDelivery,
+		arg.Url,
+		arg.EventType,
+		arg.DotNumber,
+		arg.Attempts,
+		arg.StatusCode,
+		arg.LastError,
+		arg.Delivered,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const deleteCrawlCheckpoint = `-- name: DeleteCrawlCheckpoint :exec
+DELETE FROM crawl_checkpoint
+WHERE name = ?
+`
+
+func (q *Queries) DeleteCrawlCheckpoint(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, deleteCrawlCheckpoint, name)
+	return err
+}
+
+const deleteCrawlFailure = `-- name: DeleteCrawlFailure :exec
+DELETE FROM crawl_failures
+WHERE dot_number = ?
+`
+
+func (q *Queries) DeleteCrawlFailure(ctx context.Context, dotNumber int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCrawlFailure, dotNumber)
+	return err
+}
+
+const deleteDocketFailure = `-- name: DeleteDocketFailure :exec
+DELETE FROM crawl_docket_failures
+WHERE docket_number = ?
+`
+
+func (q *Queries) DeleteDocketFailure(ctx context.Context, docketNumber int32) error {
+	_, err := q.db.ExecContext(ctx, deleteDocketFailure, docketNumber)
+	return err
+}
+
+const deleteStaleEmptyDotRanges = `-- name: DeleteStaleEmptyDotRanges :exec
+DELETE FROM empty_dot_ranges
+WHERE start_dot > ? AND end_dot <= ? AND probed_at < ?
+`
+
+type DeleteStaleEmptyDotRangesParams struct {
+	StartDot int32
+	EndDot   int32
+	ProbedAt int32
+}
+
+func (q *Queries) DeleteStaleEmptyDotRanges(ctx context.Context, arg DeleteStaleEmptyDotRangesParams) error {
+	_, err := q.db.ExecContext(ctx, deleteStaleEmptyDotRanges, arg.StartDot, arg.EndDot, arg.ProbedAt)
+	return err
+}
+
+const finishCrawlRun = `-- name: FinishCrawlRun :exec
+UPDATE crawl_runs
+SET ended_at = ?, first_dot = ?, last_dot = ?, found = ?, not_found = ?, failed = ?, written = ?, requests = ?, retries = ?, exit_reason = ?
+WHERE id = ?
+`
+
+type FinishCrawlRunParams struct {
+	EndedAt    int32
+	FirstDot   int32
+	LastDot    int32
+	Found      int32
+	NotFound   int32
+	Failed     int32
+	Written    int32
+	Requests   int32
+	Retries    int32
+	ExitReason string
+	ID         int64
+}
+
+func (q *Queries) FinishCrawlRun(ctx context.Context, arg FinishCrawlRunParams) error {
+	_, err := q.db.ExecContext(ctx, finishCrawlRun,
+		arg.EndedAt,
+		arg.FirstDot,
+		arg.LastDot,
+		arg.Found,
+		arg.NotFound,
+		arg.Failed,
+		arg.Written,
+		arg.Requests,
+		arg.Retries,
+		arg.ExitReason,
+		arg.ID,
+	)
+	return err
+}
+
+const getCrawlCheckpoint = `-- name: GetCrawlCheckpoint :one
+SELECT name, watermark, pending, updated_at FROM crawl_checkpoint
+WHERE name = ?
+`
+
+func (q *Queries) GetCrawlCheckpoint(ctx context.Context, name string) (CrawlCheckpoint, error) {
+	row := q.db.QueryRowContext(ctx, getCrawlCheckpoint, name)
+	var i CrawlCheckpoint
+	err := row.Scan(
+		&i.Name,
+		&i.Watermark,
+		&i.Pending,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getMaxDotNumber = `-- name: GetMaxDotNumber :one
+SELECT dot_number FROM fmcsa_carrier_safer
+ORDER BY dot_number DESC
+LIMIT 1
+`
+
+func (q *Queries) GetMaxDotNumber(ctx context.Context) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getMaxDotNumber)
+	var dot_number int32
+	err := row.Scan(&dot_number)
+	return dot_number, err
+}
+
+const getSaferHistoryAsOf = `-- name: GetSaferHistoryAsOf :one
+SELECT id, entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date, valid_from, valid_to FROM fmcsa_carrier_safer_history
+WHERE dot_number = ? AND valid_from <= ? AND (valid_to = 0 OR valid_to > ?)
+ORDER BY valid_from DESC, id DESC
+LIMIT 1
+`
+
+type GetSaferHistoryAsOfParams struct {
+	DotNumber int32
+	AsOf      int32
+}
+
+func (q *Queries) GetSaferHistoryAsOf(ctx context.Context, arg GetSaferHistoryAsOfParams) (FmcsaCarrierSaferHistory, error) {
+	row := q.db.QueryRowContext(ctx, getSaferHistoryAsOf, arg.DotNumber, arg.AsOf, arg.AsOf)
+	var i FmcsaCarrierSaferHistory
+	err := row.Scan(
+		&i.ID,
+		&i.EntityType,
+		&i.OperatingStatus,
+		&i.OosDate,
+		&i.LegalName,
+		&i.DbaName,
+		&i.Address,
+		&i.Telephone,
+		&i.MailingAddress,
+		&i.DotNumber,
+		&i.StateCarrierIDNumber,
+		&i.DocketNumber,
+		&i.DunsNumber,
+		&i.PowerUnits,
+		&i.Drivers,
+		&i.Mcs150FormDate,
+		&i.Mcs150MileageYear,
+		&i.CarrierOperation,
+		&i.OcAuthorizedForHire,
+		&i.OcPrivatePassengerBusiness,
+		&i.OcUsMail,
+		&i.OcLocalGovernment,
+		&i.OcExemptForHire,
+		&i.OcPrivatePassengerNonBusiness,
+		&i.OcFederalGovernment,
+		&i.OcIndianTribe,
+		&i.OcPrivateProperty,
+		&i.OcMigrant,
+		&i.OcStateGovernment,
+		&i.OcOther,
+		&i.CcGeneralFreight,
+		&i.CcMotorVehicles,
+		&i.CcBuildingMaterials,
+		&i.CcFreshProduct,
+		&i.CcPassengers,
+		&i.CcGrainFeedHay,
+		&i.CcGarbageRefuseTrash,
+		&i.CcCommoditiesDryBulk,
+		&i.CcPaperProducts,
+		&i.CcConstruction,
+		&i.CcHouseholdGoods,
+		&i.CcDriveAwayTowaway,
+		&i.CcMobileHomes,
+		&i.CcLiquidsGases,
+		&i.CcOilfieldEquipment,
+		&i.CcCoalCoke,
+		&i.CcUsMail,
+		&i.CcRefrigeratedFood,
+		&i.CcUtility,
+		&i.CcWaterwell,
+		&i.CcMetalSheetsCoilsRolls,
+		&i.CcLogsPolesBeamsLumber,
+		&i.CcMachineryLargeObjects,
+		&i.CcIntermodalContainers,
+		&i.CcLivestock,
+		&i.CcMeat,
+		&i.CcChemicals,
+		&i.CcBeverages,
+		&i.CcFarmSupplies,
+		&i.CcOther,
+		&i.UsInspectionVehicle,
+		&i.UsInspectionDriver,
+		&i.UsInspectionHazmat,
+		&i.UsInspectionIep,
+		&i.UsCrashSummary,
+		&i.CanInspectionVehicle,
+		&i.CanInspectionDriver,
+		&i.CanCrashSummary,
+		&i.SafetyRatingDate,
+		&i.SafetyRatingReviewDate,
+		&i.SafetyRating,
+		&i.SafetyRatingType,
+		&i.LatestUpdateTime,
+		&i.CreatedAt,
+		&i.InactiveDate,
+		&i.ValidFrom,
+		&i.ValidTo,
+	)
+	return i, err
+}
+
+const getSaferSnapshot = `-- name: GetSaferSnapshot :one
+SELECT entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date FROM fmcsa_carrier_safer
+WHERE dot_number = ?
+`
+
+func (q *Queries) GetSaferSnapshot(ctx context.Context, dotNumber int32) (FmcsaCarrierSafer, error) {
+	row := q.db.QueryRowContext(ctx, getSaferSnapshot, dotNumber)
+	var i FmcsaCarrierSafer
+	err := row.Scan(
+		&i.EntityType,
+		&i.OperatingStatus,
+		&i.OosDate,
+		&i.LegalName,
+		&i.DbaName,
+		&i.Address,
+		&i.Telephone,
+		&i.MailingAddress,
+		&i.DotNumber,
+		&i.StateCarrierIDNumber,
+		&i.DocketNumber,
+		&i.DunsNumber,
+		&i.PowerUnits,
+		&i.Drivers,
+		&i.Mcs150FormDate,
+		&i.Mcs150MileageYear,
+		&i.CarrierOperation,
+		&i.OcAuthorizedForHire,
+		&i.OcPrivatePassengerBusiness,
+		&i.OcUsMail,
+		&i.OcLocalGovernment,
+		&i.OcExemptForHire,
+		&i.OcPrivatePassengerNonBusiness,
+		&i.OcFederalGovernment,
+		&i.OcIndianTribe,
+		&i.OcPrivateProperty,
+		&i.OcMigrant,
+		&i.OcStateGovernment,
+		&i.OcOther,
+		&i.CcGeneralFreight,
+		&i.CcMotorVehicles,
+		&i.CcBuildingMaterials,
+		&i.CcFreshProduct,
+		&i.CcPassengers,
+		&i.CcGrainFeedHay,
+		&i.CcGarbageRefuseTrash,
+		&i.CcCommoditiesDryBulk,
+		&i.CcPaperProducts,
+		&i.CcConstruction,
+		&i.CcHouseholdGoods,
+		&i.CcDriveAwayTowaway,
+		&i.CcMobileHomes,
+		&i.CcLiquidsGases,
+		&i.CcOilfieldEquipment,
+		&i.CcCoalCoke,
+		&i.CcUsMail,
+		&i.CcRefrigeratedFood,
+		&i.CcUtility,
+		&i.CcWaterwell,
+		&i.CcMetalSheetsCoilsRolls,
+		&i.CcLogsPolesBeamsLumber,
+		&i.CcMachineryLargeObjects,
+		&i.CcIntermodalContainers,
+		&i.CcLivestock,
+		&i.CcMeat,
+		&i.CcChemicals,
+		&i.CcBeverages,
+		&i.CcFarmSupplies,
+		&i.CcOther,
+		&i.UsInspectionVehicle,
+		&i.UsInspectionDriver,
+		&i.UsInspectionHazmat,
+		&i.UsInspectionIep,
+		&i.UsCrashSummary,
+		&i.CanInspectionVehicle,
+		&i.CanInspectionDriver,
+		&i.CanCrashSummary,
+		&i.SafetyRatingDate,
+		&i.SafetyRatingReviewDate,
+		&i.SafetyRating,
+		&i.SafetyRatingType,
+		&i.LatestUpdateTime,
+		&i.CreatedAt,
+		&i.InactiveDate,
+	)
+	return i, err
+}
+
+const getSnapshotCreatedAt = `-- name: GetSnapshotCreatedAt :one
+SELECT created_at FROM fmcsa_carrier_safer
+WHERE dot_number = ?
+`
+
+func (q *Queries) GetSnapshotCreatedAt(ctx context.Context, dotNumber int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getSnapshotCreatedAt, dotNumber)
+	var created_at int32
+	err := row.Scan(&created_at)
+	return created_at, err
+}
+
+const getStoredDotNumber = `-- name: GetStoredDotNumber :one
+SELECT dot_number FROM fmcsa_carrier_safer
+WHERE dot_number = ?
+`
+
+func (q *Queries) GetStoredDotNumber(ctx context.Context, dotNumber int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getStoredDotNumber, dotNumber)
+	var dot_number int32
+	err := row.Scan(&dot_number)
+	return dot_number, err
+}
+
+const listAllCarrierEvents = `-- name: ListAllCarrierEvents :many
+SELECT id, dot_number, event_type, details, occurred_at FROM carrier_events
+WHERE occurred_at >= ? AND occurred_at < ?
+ORDER BY occurred_at, id
+LIMIT ?
+`
+
+type ListAllCarrierEventsParams struct {
+	OccurredAt   int32
+	OccurredAt_2 int32
+	Limit        int32
+}
+
+func (q *Queries) ListAllCarrierEvents(ctx context.Context, arg ListAllCarrierEventsParams) ([]CarrierEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAllCarrierEvents, arg.OccurredAt, arg.OccurredAt_2, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CarrierEvent
+	for rows.Next() {
+		var i CarrierEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.DotNumber,
+			&i.EventType,
+			&i.Details,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCarrierEvents = `-- name: ListCarrierEvents :many
+SELECT id, dot_number, event_type, details, occurred_at FROM carrier_events
+WHERE event_type = ? AND occurred_at >= ? AND occurred_at < ?
+ORDER BY occurred_at, id
+LIMIT ?
+`
+
+type ListCarrierEventsParams struct {
+	EventType    string
+	OccurredAt   int32
+	OccurredAt_2 int32
+	Limit        int32
+}
+
+func (q *Queries) ListCarrierEvents(ctx context.Context, arg ListCarrierEventsParams) ([]CarrierEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listCarrierEvents,
+		arg.EventType,
+		arg.OccurredAt,
+		arg.OccurredAt_2,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CarrierEvent
+	for rows.Next() {
+		var i CarrierEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.DotNumber,
+			&i.EventType,
+			&i.Details,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCrawlCheckpoints = `-- name: ListCrawlCheckpoints :many
+SELECT name, watermark, pending, updated_at FROM crawl_checkpoint
+ORDER BY name
+`
+
+func (q *Queries) ListCrawlCheckpoints(ctx context.Context) ([]CrawlCheckpoint, error) {
+	rows, err := q.db.QueryContext(ctx, listCrawlCheckpoints)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CrawlCheckpoint
+	for rows.Next() {
+		var i CrawlCheckpoint
+		if err := rows.Scan(
+			&i.Name,
+			&i.Watermark,
+			&i.Pending,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueCrawlFailures = `-- name: ListDueCrawlFailures :many
+SELECT dot_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at FROM crawl_failures
+WHERE next_attempt_at <= ? AND attempts < ? AND dot_number > ?
+ORDER BY dot_number
+LIMIT ?
+`
+
+type ListDueCrawlFailuresParams struct {
+	NextAttemptAt int32
+	Attempts      int32
+	DotNumber     int32
+	Limit         int32
+}
+
+func (q *Queries) ListDueCrawlFailures(ctx context.Context, arg ListDueCrawlFailuresParams) ([]CrawlFailure, error) {
+	rows, err := q.db.QueryContext(ctx, listDueCrawlFailures,
+		arg.NextAttemptAt,
+		arg.Attempts,
+		arg.DotNumber,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CrawlFailure
+	for rows.Next() {
+		var i CrawlFailure
+		if err := rows.Scan(
+			&i.DotNumber,
+			&i.Stage,
+			&i.ErrorClass,
+			&i.LastError,
+			&i.Attempts,
+			&i.LastAttemptAt,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueDocketFailures = `-- name: ListDueDocketFailures :many
+SELECT docket_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at FROM crawl_docket_failures
+WHERE next_attempt_at <= ? AND attempts < ? AND docket_number > ?
+ORDER BY docket_number
+LIMIT ?
+`
+
+type ListDueDocketFailuresParams struct {
+	NextAttemptAt int32
+	Attempts      int32
+	DocketNumber  int32
+	Limit         int32
+}
+
+func (q *Queries) ListDueDocketFailures(ctx context.Context, arg ListDueDocketFailuresParams) ([]CrawlDocketFailure, error) {
+	rows, err := q.db.QueryContext(ctx, listDueDocketFailures,
+		arg.NextAttemptAt,
+		arg.Attempts,
+		arg.DocketNumber,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CrawlDocketFailure
+	for rows.Next() {
+		var i CrawlDocketFailure
+		if err := rows.Scan(
+			&i.DocketNumber,
+			&i.Stage,
+			&i.ErrorClass,
+			&i.LastError,
+			&i.Attempts,
+			&i.LastAttemptAt,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmptyDotRanges = `-- name: ListEmptyDotRanges :many
+SELECT start_dot, end_dot, probed_at FROM empty_dot_ranges
+WHERE probed_at >= ?
+ORDER BY start_dot
+`
+
+func (q *Queries) ListEmptyDotRanges(ctx context.Context, probedAt int32) ([]EmptyDotRange, error) {
+	rows, err := q.db.QueryContext(ctx, listEmptyDotRanges, probedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EmptyDotRange
+	for rows.Next() {
+		var i EmptyDotRange
+		if err := rows.Scan(&i.StartDot, &i.EndDot, &i.ProbedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFreshDockets = `-- name: ListFreshDockets :many
+SELECT d.docket_number FROM carrier_dockets d
+JOIN fmcsa_carrier_safer s ON s.dot_number = d.dot_number
+WHERE d.docket_number >= ? AND d.docket_number <= ? AND s.created_at >= ?
+`
+
+type ListFreshDocketsParams struct {
+	DocketNumber   int32
+	DocketNumber_2 int32
+	CreatedAt      int32
+}
+
+func (q *Queries) ListFreshDockets(ctx context.Context, arg ListFreshDocketsParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listFreshDockets, arg.DocketNumber, arg.DocketNumber_2, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var docket_number int32
+		if err := rows.Scan(&docket_number); err != nil {
+			return nil, err
+		}
+		items = append(items, docket_number)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentCrawlRuns = `-- name: ListRecentCrawlRuns :many
+SELECT id, mode, started_at, ended_at, first_dot, last_dot, found, not_found, failed, written, requests, retries, exit_reason FROM crawl_runs
+ORDER BY id DESC
+LIMIT ?
+`
+
+func (q *Queries) ListRecentCrawlRuns(ctx context.Context, limit int32) ([]CrawlRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentCrawlRuns, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CrawlRun
+	for rows.Next() {
+		var i CrawlRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mode,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.FirstDot,
+			&i.LastDot,
+			&i.Found,
+			&i.NotFound,
+			&i.Failed,
+			&i.Written,
+			&i.Requests,
+			&i.Retries,
+			&i.ExitReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSaferHistory = `-- name: ListSaferHistory :many
+SELECT id, entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date, valid_from, valid_to FROM fmcsa_carrier_safer_history
+WHERE dot_number = ?
+ORDER BY valid_from, id
+`
+
+func (q *Queries) ListSaferHistory(ctx context.Context, dotNumber int32) ([]FmcsaCarrierSaferHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listSaferHistory, dotNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FmcsaCarrierSaferHistory
+	for rows.Next() {
+		var i FmcsaCarrierSaferHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntityType,
+			&i.OperatingStatus,
+			&i.OosDate,
+			&i.LegalName,
+			&i.DbaName,
+			&i.Address,
+			&i.Telephone,
+			&i.MailingAddress,
+			&i.DotNumber,
+			&i.StateCarrierIDNumber,
+			&i.DocketNumber,
+			&i.DunsNumber,
+			&i.PowerUnits,
+			&i.Drivers,
+			&i.Mcs150FormDate,
+			&i.Mcs150MileageYear,
+			&i.CarrierOperation,
+			&i.OcAuthorizedForHire,
+			&i.OcPrivatePassengerBusiness,
+			&i.OcUsMail,
+			&i.OcLocalGovernment,
+			&i.OcExemptForHire,
+			&i.OcPrivatePassengerNonBusiness,
+			&i.OcFederalGovernment,
+			&i.OcIndianTribe,
+			&i.OcPrivateProperty,
+			&i.OcMigrant,
+			&i.OcStateGovernment,
+			&i.OcOther,
+			&i.CcGeneralFreight,
+			&i.CcMotorVehicles,
+			&i.CcBuildingMaterials,
+			&i.CcFreshProduct,
+			&i.CcPassengers,
+			&i.CcGrainFeedHay,
+			&i.CcGarbageRefuseTrash,
+			&i.CcCommoditiesDryBulk,
+			&i.CcPaperProducts,
+			&i.CcConstruction,
+			&i.CcHouseholdGoods,
+			&i.CcDriveAwayTowaway,
+			&i.CcMobileHomes,
+			&i.CcLiquidsGases,
+			&i.CcOilfieldEquipment,
+			&i.CcCoalCoke,
+			&i.CcUsMail,
+			&i.CcRefrigeratedFood,
+			&i.CcUtility,
+			&i.CcWaterwell,
+			&i.CcMetalSheetsCoilsRolls,
+			&i.CcLogsPolesBeamsLumber,
+			&i.CcMachineryLargeObjects,
+			&i.CcIntermodalContainers,
+			&i.CcLivestock,
+			&i.CcMeat,
+			&i.CcChemicals,
+			&i.CcBeverages,
+			&i.CcFarmSupplies,
+			&i.CcOther,
+			&i.UsInspectionVehicle,
+			&i.UsInspectionDriver,
+			&i.UsInspectionHazmat,
+			&i.UsInspectionIep,
+			&i.UsCrashSummary,
+			&i.CanInspectionVehicle,
+			&i.CanInspectionDriver,
+			&i.CanCrashSummary,
+			&i.SafetyRatingDate,
+			&i.SafetyRatingReviewDate,
+			&i.SafetyRating,
+			&i.SafetyRatingType,
+			&i.LatestUpdateTime,
+			&i.CreatedAt,
+			&i.InactiveDate,
+			&i.ValidFrom,
+			&i.ValidTo,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnapshots = `-- name: ListSnapshots :many
+SELECT entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date FROM fmcsa_carrier_safer
+WHERE dot_number > ?
+ORDER BY dot_number
+LIMIT ?
+`
+
+type ListSnapshotsParams struct {
+	DotNumber int32
+	Limit     int32
+}
+
+func (q *Queries) ListSnapshots(ctx context.Context, arg ListSnapshotsParams) ([]FmcsaCarrierSafer, error) {
+	rows, err := q.db.QueryContext(ctx, listSnapshots, arg.DotNumber, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FmcsaCarrierSafer
+	for rows.Next() {
+		var i FmcsaCarrierSafer
+		if err := rows.Scan(
+			&i.EntityType,
+			&i.OperatingStatus,
+			&i.OosDate,
+			&i.LegalName,
+			&i.DbaName,
+			&i.Address,
+			&i.Telephone,
+			&i.MailingAddress,
+			&i.DotNumber,
+			&i.StateCarrierIDNumber,
+			&i.DocketNumber,
+			&i.DunsNumber,
+			&i.PowerUnits,
+			&i.Drivers,
+			&i.Mcs150FormDate,
+			&i.Mcs150MileageYear,
+			&i.CarrierOperation,
+			&i.OcAuthorizedForHire,
+			&i.OcPrivatePassengerBusiness,
+			&i.OcUsMail,
+			&i.OcLocalGovernment,
+			&i.OcExemptForHire,
+			&i.OcPrivatePassengerNonBusiness,
+			&i.OcFederalGovernment,
+			&i.OcIndianTribe,
+			&i.OcPrivateProperty,
+			&i.OcMigrant,
+			&i.OcStateGovernment,
+			&i.OcOther,
+			&i.CcGeneralFreight,
+			&i.CcMotorVehicles,
+			&i.CcBuildingMaterials,
+			&i.CcFreshProduct,
+			&i.CcPassengers,
+			&i.CcGrainFeedHay,
+			&i.CcGarbageRefuseTrash,
+			&i.CcCommoditiesDryBulk,
+			&i.CcPaperProducts,
+			&i.CcConstruction,
+			&i.CcHouseholdGoods,
+			&i.CcDriveAwayTowaway,
+			&i.CcMobileHomes,
+			&i.CcLiquidsGases,
+			&i.CcOilfieldEquipment,
+			&i.CcCoalCoke,
+			&i.CcUsMail,
+			&i.CcRefrigeratedFood,
+			&i.CcUtility,
+			&i.CcWaterwell,
+			&i.CcMetalSheetsCoilsRolls,
+			&i.CcLogsPolesBeamsLumber,
+			&i.CcMachineryLargeObjects,
+			&i.CcIntermodalContainers,
+			&i.CcLivestock,
+			&i.CcMeat,
+			&i.CcChemicals,
+			&i.CcBeverages,
+			&i.CcFarmSupplies,
+			&i.CcOther,
+			&i.UsInspectionVehicle,
+			&i.UsInspectionDriver,
+			&i.UsInspectionHazmat,
+			&i.UsInspectionIep,
+			&i.UsCrashSummary,
+			&i.CanInspectionVehicle,
+			&i.CanInspectionDriver,
+			&i.CanCrashSummary,
+			&i.SafetyRatingDate,
+			&i.SafetyRatingReviewDate,
+			&i.SafetyRating,
+			&i.SafetyRatingType,
+			&i.LatestUpdateTime,
+			&i.CreatedAt,
+			&i.InactiveDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnapshotsByCreatedAt = `-- name: ListSnapshotsByCreatedAt :many
+SELECT dot_number, created_at FROM fmcsa_carrier_safer
+WHERE created_at < ? AND (created_at > ? OR (created_at = ? AND dot_number > ?))
+ORDER BY created_at, dot_number
+LIMIT ?
+`
+
+type ListSnapshotsByCreatedAtParams struct {
+	CreatedAt   int32
+	CreatedAt_2 int32
+	CreatedAt_3 int32
+	DotNumber   int32
+	Limit       int32
+}
+
+type ListSnapshotsByCreatedAtRow struct {
+	DotNumber int32
+	CreatedAt int32
+}
+
+func (q *Queries) ListSnapshotsByCreatedAt(ctx context.Context, arg ListSnapshotsByCreatedAtParams) ([]ListSnapshotsByCreatedAtRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSnapshotsByCreatedAt,
+		arg.CreatedAt,
+		arg.CreatedAt_2,
+		arg.CreatedAt_3,
+		arg.DotNumber,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSnapshotsByCreatedAtRow
+	for rows.Next() {
+		var i ListSnapshotsByCreatedAtRow
+		if err := rows.Scan(&i.DotNumber, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnapshotsByLatestUpdateTime = `-- name: ListSnapshotsByLatestUpdateTime :many
+SELECT dot_number, latest_update_time FROM fmcsa_carrier_safer
+WHERE latest_update_time < ? AND (latest_update_time > ? OR (latest_update_time = ? AND dot_number > ?))
+ORDER BY latest_update_time, dot_number
+LIMIT ?
+`
+
+type ListSnapshotsByLatestUpdateTimeParams struct {
+	LatestUpdateTime   sql.NullTime
+	LatestUpdateTime_2 sql.NullTime
+	LatestUpdateTime_3 sql.NullTime
+	DotNumber          int32
+	Limit              int32
+}
+
+type ListSnapshotsByLatestUpdateTimeRow struct {
+	DotNumber        int32
+	LatestUpdateTime sql.NullTime
+}
+
+func (q *Queries) ListSnapshotsByLatestUpdateTime(ctx context.Context, arg ListSnapshotsByLatestUpdateTimeParams) ([]ListSnapshotsByLatestUpdateTimeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSnapshotsByLatestUpdateTime,
+		arg.LatestUpdateTime,
+		arg.LatestUpdateTime_2,
+		arg.LatestUpdateTime_3,
+		arg.DotNumber,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSnapshotsByLatestUpdateTimeRow
+	for rows.Next() {
+		var i ListSnapshotsByLatestUpdateTimeRow
+		if err := rows.Scan(&i.DotNumber, &i.LatestUpdateTime); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markSnapshotInactive = `-- name: MarkSnapshotInactive :exec
+INSERT INTO fmcsa_carrier_safer (entity_type, operating_status, oos_date, legal_name, dba_name, address, telephone, mailing_address, dot_number, state_carrier_id_number, docket_number, duns_number, power_units, drivers, mcs_150_form_date, mcs_150_mileage_year, carrier_operation, oc_authorized_for_hire, oc_private_passenger_business, oc_us_mail, oc_local_government, oc_exempt_for_hire, oc_private_passenger_non_business, oc_federal_government, oc_indian_tribe, oc_private_property, oc_migrant, oc_state_government, oc_other, cc_general_freight, cc_motor_vehicles, cc_building_materials, cc_fresh_product, cc_passengers, cc_grain_feed_hay, cc_garbage_refuse_trash, cc_commodities_dry_bulk, cc_paper_products, cc_construction, cc_household_goods, cc_drive_away_towaway, cc_mobile_homes, cc_liquids_gases, cc_oilfield_equipment, cc_coal_coke, cc_us_mail, cc_refrigerated_food, cc_utility, cc_waterwell, cc_metal_sheets_coils_rolls, cc_logs_poles_beams_lumber, cc_machinery_large_objects, cc_intermodal_containers, cc_livestock, cc_meat, cc_chemicals, cc_beverages, cc_farm_supplies, cc_other, us_inspection_vehicle, us_inspection_driver, us_inspection_hazmat, us_inspection_iep, us_crash_summary, can_inspection_vehicle, can_inspection_driver, can_crash_summary, safety_rating_date, safety_rating_review_date, safety_rating, safety_rating_type, latest_update_time, created_at, inactive_date)
+VALUES
+	(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE operating_status = VALUES(operating_status), inactive_date = COALESCE(inactive_date, VALUES(inactive_date)), created_at = VALUES(created_at)
+`
+
+type MarkSnapshotInactiveParams struct {
+	EntityType                    string
+	OperatingStatus               string
+	OosDate                       sql.NullTime
+	LegalName                     string
+	DbaName                       string
+	Address                       string
+	Telephone                     string
+	MailingAddress                string
+	DotNumber                     int32
+	StateCarrierIDNumber          string
+	DocketNumber                  string
+	DunsNumber                    string
+	PowerUnits                    int32
+	Drivers                       int32
+	Mcs150FormDate                sql.NullTime
+	Mcs150MileageYear             string
+	CarrierOperation              string
+	OcAuthorizedForHire           bool
+	OcPrivatePassengerBusiness    bool
+	OcUsMail                      bool
+	OcLocalGovernment             bool
+	OcExemptForHire               bool
+	OcPrivatePassengerNonBusiness bool
+	OcFederalGovernment           bool
+	OcIndianTribe                 bool
+	OcPrivateProperty             bool
+	OcMigrant                     bool
+	OcStateGovernment             bool
+	OcOther                       string
+	CcGeneralFreight              bool
+	CcMotorVehicles               bool
+	CcBuildingMaterials           bool
+	CcFreshProduct                bool
+	CcPassengers                  bool
+	CcGrainFeedHay                bool
+	CcGarbageRefuseTrash          bool
+	CcCommoditiesDryBulk          bool
+	CcPaperProducts               bool
+	CcConstruction                bool
+	CcHouseholdGoods              bool
+	CcDriveAwayTowaway            bool
+	CcMobileHomes                 bool
+	CcLiquidsGases                bool
+	CcOilfieldEquipment           bool
+	CcCoalCoke                    bool
+	CcUsMail                      bool
+	CcRefrigeratedFood            bool
+	CcUtility                     bool
+	CcWaterwell                   bool
+	CcMetalSheetsCoilsRolls       bool
+	CcLogsPolesBeamsLumber        bool
+	CcMachineryLargeObjects       bool
+	CcIntermodalContainers        bool
+	CcLivestock                   bool
+	CcMeat                        bool
+	CcChemicals                   bool
+	CcBeverages                   bool
+	CcFarmSupplies                bool
+	CcOther                       string
+	UsInspectionVehicle           json.RawMessage
+	UsInspectionDriver            json.RawMessage
+	UsInspectionHazmat            json.RawMessage
+	UsInspectionIep               json.RawMessage
+	UsCrashSummary                json.RawMessage
+	CanInspectionVehicle          json.RawMessage
+	CanInspectionDriver           json.RawMessage
+	CanCrashSummary               json.RawMessage
+	SafetyRatingDate              sql.NullTime
+	SafetyRatingReviewDate        sql.NullTime
+	SafetyRating                  string
+	SafetyRatingType              string
+	LatestUpdateTime              sql.NullTime
+	CreatedAt                     int32
+	InactiveDate                  sql.NullTime
+}
+
+func (q *Queries) MarkSnapshotInactive(ctx context.Context, arg MarkSnapshotInactiveParams) error {
+	_, err := q.db.ExecContext(ctx, markSnapshotInactive,
+		arg.EntityType,
+		arg.OperatingStatus,
+		arg.OosDate,
+		arg.LegalName,
+		arg.DbaName,
+		arg.Address,
+		arg.Telephone,
+		arg.MailingAddress,
+		arg.DotNumber,
+		arg.StateCarrierIDNumber,
+		arg.DocketNumber,
+		arg.DunsNumber,
+		arg.PowerUnits,
+		arg.Drivers,
+		arg.Mcs150FormDate,
+		arg.Mcs150MileageYear,
+		arg.CarrierOperation,
+		arg.OcAuthorizedForHire,
+		arg.OcPrivatePassengerBusiness,
+		arg.OcUsMail,
+		arg.OcLocalGovernment,
+		arg.OcExemptForHire,
+		arg.OcPrivatePassengerNonBusiness,
+		arg.OcFederalGovernment,
+		arg.OcIndianTribe,
+		arg.OcPrivateProperty,
+		arg.OcMigrant,
+		arg.OcStateGovernment,
+		arg.OcOther,
+		arg.CcGeneralFreight,
+		arg.CcMotorVehicles,
+		arg.CcBuildingMaterials,
+		arg.CcFreshProduct,
+		arg.CcPassengers,
+		arg.CcGrainFeedHay,
+		arg.CcGarbageRefuseTrash,
+		arg.CcCommoditiesDryBulk,
+		arg.CcPaperProducts,
+		arg.CcConstruction,
+		arg.CcHouseholdGoods,
+		arg.CcDriveAwayTowaway,
+		arg.CcMobileHomes,
+		arg.CcLiquidsGases,
+		arg.CcOilfieldEquipment,
+		arg.CcCoalCoke,
+		arg.CcUsMail,
+		arg.CcRefrigeratedFood,
+		arg.CcUtility,
+		arg.CcWaterwell,
+		arg.CcMetalSheetsCoilsRolls,
+		arg.CcLogsPolesBeamsLumber,
+		arg.CcMachineryLargeObjects,
+		arg.CcIntermodalContainers,
+		arg.CcLivestock,
+		arg.CcMeat,
+		arg.CcChemicals,
+		arg.CcBeverages,
+		arg.CcFarmSupplies,
+		arg.CcOther,
+		arg.UsInspectionVehicle,
+		arg.UsInspectionDriver,
+		arg.UsInspectionHazmat,
+		arg.UsInspectionIep,
+		arg.UsCrashSummary,
+		arg.CanInspectionVehicle,
+		arg.CanInspectionDriver,
+		arg.CanCrashSummary,
+		arg.SafetyRatingDate,
+		arg.SafetyRatingReviewDate,
+		arg.SafetyRating,
+		arg.SafetyRatingType,
+		arg.LatestUpdateTime,
+		arg.CreatedAt,
+		arg.InactiveDate,
+	)
+	return err
+}
+
+const recordCrawlFailure = `-- name: RecordCrawlFailure :exec
+INSERT INTO crawl_failures (dot_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at)
+VALUES
+	(?, ?, ?, ?, 1, ?, ?)
+ON DUPLICATE KEY UPDATE stage = VALUES(stage), error_class = VALUES(error_class), last_error = VALUES(last_error), attempts = attempts + 1, last_attempt_at = VALUES(last_attempt_at), next_attempt_at = VALUES(next_attempt_at)
+`
+
+type RecordCrawlFailureParams struct {
+	DotNumber     int32
+	Stage         string
+	ErrorClass    string
+	LastError     string
+	LastAttemptAt int32
+	NextAttemptAt int32
+}
+
+func (q *Queries) RecordCrawlFailure(ctx context.Context, arg RecordCrawlFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordCrawlFailure,
+		arg.DotNumber,
+		arg.Stage,
+		arg.ErrorClass,
+		arg.LastError,
+		arg.LastAttemptAt,
+		arg.NextAttemptAt,
+	)
+	return err
+}
+
+const recordDocketFailure = `-- name: RecordDocketFailure :exec
+INSERT INTO crawl_docket_failures (docket_number, stage, error_class, last_error, attempts, last_attempt_at, next_attempt_at)
+VALUES
+	(?, ?, ?, ?, 1, ?, ?)
+ON DUPLICATE KEY UPDATE stage = VALUES(stage), error_class = VALUES(error_class), last_error = VALUES(last_error), attempts = attempts + 1, last_attempt_at = VALUES(last_attempt_at), next_attempt_at = VALUES(next_attempt_at)
+`
+
+type RecordDocketFailureParams struct {
+	DocketNumber  int32
+	Stage         string
+	ErrorClass    string
+	LastError     string
+	LastAttemptAt int32
+	NextAttemptAt int32
+}
+
+func (q *Queries) RecordDocketFailure(ctx context.Context, arg RecordDocketFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordDocketFailure,
+		arg.DocketNumber,
+		arg.Stage,
+		arg.ErrorClass,
+		arg.LastError,
+		arg.LastAttemptAt,
+		arg.NextAttemptAt,
+	)
+	return err
+}
+
+const saveCarrierDocket = `-- name: SaveCarrierDocket :exec
+REPLACE INTO carrier_dockets (docket_number, dot_number, updated_at)
+VALUES
+	(?, ?, ?)
+`
+
+type SaveCarrierDocketParams struct {
+	DocketNumber int32
+	DotNumber    int32
+	UpdatedAt    int32
+}
+
+func (q *Queries) SaveCarrierDocket(ctx context.Context, arg SaveCarrierDocketParams) error {
+	_, err := q.db.ExecContext(ctx, saveCarrierDocket, arg.DocketNumber, arg.DotNumber, arg.UpdatedAt)
+	return err
+}
+
+const saveCrawlCheckpoint = `-- name: SaveCrawlCheckpoint :exec
+REPLACE INTO crawl_checkpoint (name, watermark, pending, updated_at)
+VALUES
+	(?, ?, ?, ?)
+`
+
+type SaveCrawlCheckpointParams struct {
+	Name      string
+	Watermark int32
+	Pending   json.RawMessage
+	UpdatedAt int32
+}
+
+func (q *Queries) SaveCrawlCheckpoint(ctx context.Context, arg SaveCrawlCheckpointParams) error {
+	_, err := q.db.ExecContext(ctx, saveCrawlCheckpoint,
+		arg.Name,
+		arg.Watermark,
+		arg.Pending,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const saveEmptyDotRange = `-- name: SaveEmptyDotRange :exec
+REPLACE INTO empty_dot_ranges (start_dot, end_dot, probed_at)
+VALUES
+	(?, ?, ?)
+`
+
+type SaveEmptyDotRangeParams struct {
+	StartDot int32
+	EndDot   int32
+	ProbedAt int32
+}
+
+func (q *Queries) SaveEmptyDotRange(ctx context.Context, arg SaveEmptyDotRangeParams) error {
+	_, err := q.db.ExecContext(ctx, saveEmptyDotRange, arg.StartDot, arg.EndDot, arg.ProbedAt)
+	return err
+}
@@ -2,10 +2,10 @@ package dao
 
 import (
 	"database/sql"
-	"log"
 
 	"carrierleads.com/internal/dao/carrierleads"
 	_ "github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
 )
 
 type Dao struct {
@@ -16,7 +16,7 @@ type Dao struct {
 func Instance(s string) (d Dao) {
 	db, err := sql.Open("mysql", s)
 	if err != nil {
-		log.Fatal("failed to open database", err)
+		log.WithError(err).Fatal("failed to open database")
 	}
 
 	return Dao{
@@ -0,0 +1,237 @@
+// Package daotest fakes the database behind a dao.Dao so that the code using it can
+// be tested without a MySQL server. The statements are told apart by the sqlc query
+// name they start with, e.g. "GetCrawlCheckpoint", and answered by the handlers the
+// test registers. Every statement is recorded along with its arguments, and so are
+// the ends of the transactions, as "COMMIT" and "ROLLBACK".
+package daotest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/dao/carrierleads"
+)
+
+// Handler answers a statement called with args. The rows it returns are the result
+// of a query, an exec ignores them.
+type Handler func(args []driver.Value) (*Rows, error)
+
+// Rows is the result of a query
+type Rows struct {
+	Columns []string
+	Values  [][]driver.Value
+}
+
+// Call is a statement run against the fake database
+type Call struct {
+	Name string
+	Args []driver.Value
+}
+
+// DB is a fake database. A statement without handler succeeds, a query without
+// handler returns no rows.
+type DB struct {
+	mu       sync.Mutex
+	handlers map[string]Handler
+	calls    []Call
+}
+
+// New returns a fake database and a Dao connected to it
+func New() (*DB, dao.Dao) {
+	db := &DB{handlers: map[string]Handler{}}
+	name := "daotest" + strconv.FormatInt(nextID.Add(1), 10)
+	sql.Register(name, fakeDriver{db})
+	conn, err := sql.Open(name, "")
+	if err != nil {
+		panic(err)
+	}
+	return db, dao.Dao{DB: conn, Queries: carrierleads.New(conn)}
+}
+
+var nextID atomic.Int64
+
+// Handle answers the statements of the query name with h
+func (db *DB) Handle(name string, h Handler) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.handlers[name] = h
+}
+
+// Calls returns the arguments of every call of the query name, in order
+func (db *DB) Calls(name string) [][]driver.Value {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var args [][]driver.Value
+	for _, c := range db.calls {
+		if c.Name == name {
+			args = append(args, c.Args)
+		}
+	}
+	return args
+}
+
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+func (db *DB) run(query string, named []driver.NamedValue) (*Rows, error) {
+	name := query
+	if m := queryName.FindStringSubmatch(query); m != nil {
+		name = m[1]
+	}
+	args := make([]driver.Value, len(named))
+	for i, v := range named {
+		args[i] = v.Value
+	}
+	db.mu.Lock()
+	db.calls = append(db.calls, Call{Name: name, Args: args})
+	h := db.handlers[name]
+	db.mu.Unlock()
+	if h == nil {
+		return &Rows{}, nil
+	}
+	rows, err := h(args)
+	if rows == nil && err == nil {
+		rows = &Rows{}
+	}
+	return rows, err
+}
+
+// StructRows returns the rows holding items, structs whose fields are the columns in
+// order, as sqlc selects them
+func StructRows(items ...interface{}) *Rows {
+	rows := &Rows{}
+	for i, item := range items {
+		v := reflect.ValueOf(item)
+		values := make([]driver.Value, v.NumField())
+		for f := range values {
+			if i == 0 {
+				rows.Columns = append(rows.Columns, v.Type().Field(f).Name)
+			}
+			value, err := driver.DefaultParameterConverter.ConvertValue(v.Field(f).Interface())
+			if err != nil {
+				panic(fmt.Sprintf("column %s: %v", v.Type().Field(f).Name, err))
+			}
+			values[f] = value
+		}
+		rows.Values = append(rows.Values, values)
+	}
+	return rows
+}
+
+// Value returns the single row holding values, e.g. the result of a COUNT
+func Value(values ...driver.Value) *Rows {
+	rows := &Rows{Values: [][]driver.Value{values}}
+	for i := range values {
+		rows.Columns = append(rows.Columns, "column"+strconv.Itoa(i))
+	}
+	return rows
+}
+
+// Int returns the value of an integer argument
+func Int(v driver.Value) int {
+	switch v := v.(type) {
+	case int64:
+		return int(v)
+	case time.Time:
+		return int(v.Unix())
+	}
+	panic(fmt.Sprintf("not an integer: %#v", v))
+}
+
+type fakeDriver struct {
+	db *DB
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return conn{d.db}, nil
+}
+
+type conn struct {
+	db *DB
+}
+
+func (c conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("daotest: statements aren't prepared")
+}
+
+func (c conn) Close() error {
+	return nil
+}
+
+func (c conn) Begin() (driver.Tx, error) {
+	return tx{c.db}, nil
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{Rows: r}, nil
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.db.run(query, args); err != nil {
+		return nil, err
+	}
+	return result{}, nil
+}
+
+// result is the result of every exec, a single row inserted with id 1
+type result struct{}
+
+func (result) LastInsertId() (int64, error) { return 1, nil }
+func (result) RowsAffected() (int64, error) { return 1, nil }
+
+// CheckNamedValue accepts every argument the default converter does, so that the
+// argument lists built by reflection go through as they are
+func (c conn) CheckNamedValue(v *driver.NamedValue) (err error) {
+	v.Value, err = driver.DefaultParameterConverter.ConvertValue(v.Value)
+	return
+}
+
+type tx struct {
+	db *DB
+}
+
+func (t tx) Commit() error {
+	_, err := t.db.run("COMMIT", nil)
+	return err
+}
+
+func (t tx) Rollback() error {
+	_, err := t.db.run("ROLLBACK", nil)
+	return err
+}
+
+type rows struct {
+	*Rows
+	next int
+}
+
+func (r *rows) Columns() []string {
+	return r.Rows.Columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.Values) {
+		return io.EOF
+	}
+	copy(dest, r.Values[r.next])
+	r.next++
+	return nil
+}
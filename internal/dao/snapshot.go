@@ -0,0 +1,116 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+)
+
+// SnapshotWrite is a carrier written to the fmcsa_carrier_safer table
+type SnapshotWrite struct {
+	Params carrierleads.CreateSaferSnapshotParams
+	// Inactive only marks the stored carrier inactive, keeping what is known about it
+	Inactive bool
+	// Changed tells that the carrier differs materially from the stored one, its
+	// current version in the history is closed. It is set by the Differ.
+	Changed bool
+	// Events are the lifecycle events the write makes, stored in carrier_events. They
+	// are set by the Differ.
+	Events []carrierleads.CreateCarrierEventParams
+}
+
+// Differ compares w with stored, the carrier it replaces or nil if there is none, and
+// sets the Changed and Events of w
+type Differ func(stored *carrierleads.FmcsaCarrierSafer, w *SnapshotWrite) error
+
+// WriteSnapshots stores the carriers with multi-row statements inside a transaction
+// and appends them to fmcsa_carrier_safer_history. The stored carriers are loaded in
+// a single query and compared with the writes by diff, a carrier written twice is
+// compared with its previous write. The changed carriers get a new version, the
+// others only get one if they have none yet, e.g. because they were stored before
+// the history was kept. Their events are stored in the same transaction.
+//
+// The writes are updated with the outcome of diff.
+func (d Dao) WriteSnapshots(ctx context.Context, writes []SnapshotWrite, diff Differ) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := d.Queries.WithTx(tx)
+
+	dots := make([]int32, len(writes))
+	for i, w := range writes {
+		dots[i] = w.Params.DotNumber
+	}
+	rows, err := q.GetSaferSnapshots(ctx, dots)
+	if err != nil {
+		return err
+	}
+	if err = diffWrites(rows, writes, diff); err != nil {
+		return err
+	}
+
+	var replace []carrierleads.CreateSaferSnapshotParams
+	var inactive []carrierleads.MarkSnapshotInactiveParams
+	var changed []int32
+	var events []carrierleads.CreateCarrierEventParams
+	for _, w := range writes {
+		if w.Inactive {
+			inactive = append(inactive, carrierleads.MarkSnapshotInactiveParams(w.Params))
+		} else {
+			replace = append(replace, w.Params)
+		}
+		if w.Changed {
+			changed = append(changed, w.Params.DotNumber)
+		}
+		events = append(events, w.Events...)
+	}
+
+	if err = q.CreateSaferSnapshots(ctx, replace); err != nil {
+		return err
+	}
+	if err = q.MarkSnapshotsInactive(ctx, inactive); err != nil {
+		return err
+	}
+	now := int32(time.Now().Unix())
+	if err = q.CloseSaferHistories(ctx, now, changed); err != nil {
+		return err
+	}
+	if err = q.AppendSaferHistories(ctx, now, dots); err != nil {
+		return err
+	}
+	if err = q.CreateCarrierEvents(ctx, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// diffWrites compares every write with the carrier it replaces: the stored row, or
+// the previous write of the same carrier
+func diffWrites(rows []carrierleads.FmcsaCarrierSafer, writes []SnapshotWrite, diff Differ) error {
+	stored := make(map[int32]*carrierleads.FmcsaCarrierSafer, len(rows))
+	for i := range rows {
+		stored[rows[i].DotNumber] = &rows[i]
+	}
+	for i := range writes {
+		w := &writes[i]
+		dot := w.Params.DotNumber
+		if err := diff(stored[dot], w); err != nil {
+			return err
+		}
+		// what the carrier reads back as once the write is done
+		row := carrierleads.FmcsaCarrierSafer(w.Params)
+		if prev := stored[dot]; w.Inactive && prev != nil {
+			// marking a carrier inactive keeps the date it was first seen inactive
+			row = *prev
+			row.OperatingStatus = w.Params.OperatingStatus
+			if !row.InactiveDate.Valid {
+				row.InactiveDate = w.Params.InactiveDate
+			}
+		}
+		stored[dot] = &row
+	}
+	return nil
+}
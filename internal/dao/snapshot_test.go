@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"carrierleads.com/internal/dao/carrierleads"
+)
+
+func Test_diffWrites(t *testing.T) {
+	inactiveDate := sql.NullTime{Time: time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC), Valid: true}
+	rows := []carrierleads.FmcsaCarrierSafer{
+		{DotNumber: 1, OperatingStatus: "AUTHORIZED", PowerUnits: 3},
+	}
+	writes := []SnapshotWrite{
+		{Params: carrierleads.CreateSaferSnapshotParams{DotNumber: 1, OperatingStatus: "AUTHORIZED", PowerUnits: 6}},
+		{Params: carrierleads.CreateSaferSnapshotParams{DotNumber: 2, OperatingStatus: "AUTHORIZED", PowerUnits: 1}},
+		// the same carrier found again in the batch, e.g. through a second docket
+		{Params: carrierleads.CreateSaferSnapshotParams{DotNumber: 2, OperatingStatus: "AUTHORIZED", PowerUnits: 1}},
+		{Params: carrierleads.CreateSaferSnapshotParams{DotNumber: 1, OperatingStatus: "INACTIVE", InactiveDate: inactiveDate}, Inactive: true},
+	}
+
+	// records the power units and status each write is compared with, -1 if none
+	var compared []int32
+	var statuses []string
+	diff := func(stored *carrierleads.FmcsaCarrierSafer, w *SnapshotWrite) error {
+		if stored == nil {
+			compared = append(compared, -1)
+			statuses = append(statuses, "")
+			return nil
+		}
+		compared = append(compared, stored.PowerUnits)
+		statuses = append(statuses, stored.OperatingStatus)
+		w.Changed = stored.PowerUnits != w.Params.PowerUnits
+		return nil
+	}
+	if err := diffWrites(rows, writes, diff); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int32{3, -1, 1, 6}; !reflect.DeepEqual(compared, want) {
+		t.Errorf("writes compared with power units %v, want %v", compared, want)
+	}
+	if want := []string{"AUTHORIZED", "", "AUTHORIZED", "AUTHORIZED"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("writes compared with statuses %v, want %v", statuses, want)
+	}
+	if !writes[0].Changed || writes[2].Changed {
+		t.Errorf("Changed = %v, %v, want true, false", writes[0].Changed, writes[2].Changed)
+	}
+}
@@ -0,0 +1,121 @@
+package safer
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VolatileFields change on SAFER without the carrier changing, they are left out of
+// a diff by IgnoreVolatile
+var VolatileFields = []string{"latest_update_date"}
+
+// Change is a field that differs between two snapshots
+type Change struct {
+	// Field is the path of the field built from its JSON names, e.g. safety.rating
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+	// Added and Removed are the elements that differ in a list field, which is
+	// compared as a set
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+type diffOptions struct {
+	ignored map[string]bool
+}
+
+// DiffOption configures DiffSnapshots
+type DiffOption func(*diffOptions)
+
+// IgnoreFields leaves the fields at paths out of the diff. The path of a nested
+// struct, e.g. us_crashes, leaves out all of its fields.
+func IgnoreFields(paths ...string) DiffOption {
+	return func(o *diffOptions) {
+		for _, path := range paths {
+			o.ignored[path] = true
+		}
+	}
+}
+
+// IgnoreVolatile leaves the VolatileFields out of the diff
+func IgnoreVolatile() DiffOption {
+	return IgnoreFields(VolatileFields...)
+}
+
+var timeType = reflect.TypeOf((*time.Time)(nil))
+
+// DiffSnapshots returns the fields that differ between old and new, in the order they
+// are declared in CompanySnapshot. The lists, like CargoCarried and MCMXFFNumbers, are
+// compared as sets and the dates regardless of their location. A nil snapshot is
+// compared as an empty one.
+func DiffSnapshots(old, new *CompanySnapshot, opts ...DiffOption) []Change {
+	o := diffOptions{ignored: map[string]bool{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if old == nil {
+		old = &CompanySnapshot{}
+	}
+	if new == nil {
+		new = &CompanySnapshot{}
+	}
+	var changes []Change
+	diffStruct(reflect.ValueOf(*old), reflect.ValueOf(*new), "", o.ignored, &changes)
+	return changes
+}
+
+func diffStruct(a, b reflect.Value, prefix string, ignored map[string]bool, changes *[]Change) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		path := prefix + strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if ignored[path] {
+			continue
+		}
+		x, y := a.Field(i), b.Field(i)
+		switch {
+		case x.Kind() == reflect.Struct:
+			diffStruct(x, y, path+".", ignored, changes)
+		case x.Type() == timeType:
+			if !sameTime(x.Interface().(*time.Time), y.Interface().(*time.Time)) {
+				*changes = append(*changes, Change{Field: path, Old: x.Interface(), New: y.Interface()})
+			}
+		case x.Kind() == reflect.Slice:
+			oldSet, newSet := x.Interface().([]string), y.Interface().([]string)
+			added, removed := setDiff(oldSet, newSet), setDiff(newSet, oldSet)
+			if len(added) > 0 || len(removed) > 0 {
+				*changes = append(*changes, Change{Field: path, Old: oldSet, New: newSet, Added: added, Removed: removed})
+			}
+		default:
+			if x.Interface() != y.Interface() {
+				*changes = append(*changes, Change{Field: path, Old: x.Interface(), New: y.Interface()})
+			}
+		}
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// setDiff returns the sorted elements of b that aren't in a
+func setDiff(a, b []string) []string {
+	in := make(map[string]bool, len(a))
+	for _, s := range a {
+		in[s] = true
+	}
+	var ret []string
+	for _, s := range b {
+		if !in[s] {
+			ret = append(ret, s)
+			in[s] = true
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
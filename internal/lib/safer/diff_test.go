@@ -0,0 +1,60 @@
+package safer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	date := time.Date(2022, 10, 29, 0, 0, 0, 0, time.UTC)
+	sameDate := date.In(time.FixedZone("EST", -5*3600))
+	later := date.AddDate(0, 3, 0)
+	old := &CompanySnapshot{
+		DOTNumber:        "123",
+		OperatingStatus:  "AUTHORIZED FOR Property",
+		PowerUnits:       3,
+		CargoCarried:     []string{"General Freight", "Household Goods"},
+		MCMXFFNumbers:    []string{"MC-1"},
+		Safety:           SafetyRating{Rating: "Satisfactory", RatingDate: &date},
+		LatestUpdateDate: &date,
+	}
+	new := &CompanySnapshot{
+		DOTNumber:        "123",
+		OperatingStatus:  "OUT-OF-SERVICE",
+		PowerUnits:       6,
+		CargoCarried:     []string{"Household Goods", "General Freight"},
+		MCMXFFNumbers:    []string{"MC-2", "MC-1"},
+		Safety:           SafetyRating{Rating: "Conditional", RatingDate: &sameDate},
+		LatestUpdateDate: &later,
+		OutOfServiceDate: &later,
+	}
+
+	want := []Change{
+		{Field: "safety.rating", Old: "Satisfactory", New: "Conditional"},
+		{Field: "latest_update_date", Old: &date, New: &later},
+		{Field: "out_of_service_date", Old: (*time.Time)(nil), New: &later},
+		{Field: "mc_mx_ff_numbers", Old: []string{"MC-1"}, New: []string{"MC-2", "MC-1"}, Added: []string{"MC-2"}},
+		{Field: "operating_status", Old: "AUTHORIZED FOR Property", New: "OUT-OF-SERVICE"},
+		{Field: "power_units", Old: 3, New: 6},
+	}
+	if got := DiffSnapshots(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffSnapshots() = %+v, want %+v", got, want)
+	}
+
+	got := DiffSnapshots(old, new, IgnoreVolatile(), IgnoreFields("safety", "power_units"))
+	var fields []string
+	for _, c := range got {
+		fields = append(fields, c.Field)
+	}
+	if want := []string{"out_of_service_date", "mc_mx_ff_numbers", "operating_status"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("DiffSnapshots() with ignored fields = %v, want %v", fields, want)
+	}
+
+	if got := DiffSnapshots(old, old); len(got) != 0 {
+		t.Errorf("DiffSnapshots() of the same snapshot = %+v, want none", got)
+	}
+	if got := DiffSnapshots(nil, &CompanySnapshot{Drivers: 1}); len(got) != 1 || got[0].Field != "drivers" {
+		t.Errorf("DiffSnapshots() from nil = %+v, want drivers", got)
+	}
+}
@@ -10,6 +10,9 @@ type CompanyResult struct {
 }
 
 // CompanySnapshot data parsed from the https://safer.fmcsa.dot.gov/CompanySnapshot.aspx website
+//
+// Note: InactiveDate is never parsed from a snapshot, it is set by callers storing inactive records
+// (see RecordInactiveError)
 type CompanySnapshot struct {
 	USVehicleInspections     InspectionSummary `json:"us_vehicle_inspections"`
 	USDriverInspections      InspectionSummary `json:"us_driver_inspections"`
@@ -22,6 +25,7 @@ type CompanySnapshot struct {
 	Safety                   SafetyRating      `json:"safety"`
 	LatestUpdateDate         *time.Time        `json:"latest_update_date"`
 	OutOfServiceDate         *time.Time        `json:"out_of_service_date"`
+	InactiveDate             *time.Time        `json:"inactive_date,omitempty"`
 	MCS150FormDate           *time.Time        `json:"mcs_150_form_date"`
 	OperationClassification  []string          `json:"operation_classification"`
 	CarrierOperation         []string          `json:"carrier_operation"`
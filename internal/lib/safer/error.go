@@ -1,8 +1,97 @@
 package safer
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 var (
 	// ErrCompanyNotFound is thrown when a company is not found for the searched MC/MX/DOT number
 	ErrCompanyNotFound = errors.New("company not found")
+	// ErrRecordInactive is thrown when the searched MC/MX/DOT number belongs to an inactive record
+	ErrRecordInactive = errors.New("record inactive")
 )
+
+// RecordInactiveError is returned when the searched MC/MX/DOT number belongs to an inactive
+// record. It matches ErrRecordInactive with errors.Is.
+type RecordInactiveError struct {
+	// InactiveDate is the date the record went inactive, nil if SAFER doesn't mention it
+	InactiveDate *time.Time
+}
+
+func (e *RecordInactiveError) Error() string {
+	return ErrRecordInactive.Error()
+}
+
+func (e *RecordInactiveError) Is(target error) bool {
+	return target == ErrRecordInactive
+}
+
+// StatusError is returned when SAFER responds with a status other than 200 OK
+type StatusError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter is the delay requested by the Retry-After header, 0 if absent
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return e.Status + " Response from SAFER"
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// TransportError is returned when the request to SAFER could not be completed, e.g. the
+// connection failed or timed out
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return "request to SAFER failed: " + e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned when the page returned by SAFER could not be parsed, e.g.
+// because its layout changed
+type ParseError struct {
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return "failed to parse SAFER page: " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parse a Retry-After header, either a number of seconds or an http date
+func parseRetryAfter(text string, now time.Time) time.Duration {
+	if text == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(text); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(text); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package safer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+func TestScrape_Errors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/not-found", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(notFoundHTML))
+	})
+	mux.HandleFunc("/inactive", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>SAFER Web - Company Snapshot RECORD INACTIVE</title></head><body><p>USDOT 1 INACTIVE since 03/15/2019</p></body></html>`))
+	})
+	mux.HandleFunc("/throttled", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	mux.HandleFunc("/bad-request", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	mux.HandleFunc("/layout", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>SAFER Web - Company Snapshot</title></head><body><p>redesigned</p></body></html>`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	get := func(path string) error {
+		_, err := NewClient(WithCompanySnapshotURL(ts.URL + path)).GetCompanyByDOTNumber("1")
+		return err
+	}
+
+	if err := get("/not-found"); !errors.Is(err, ErrCompanyNotFound) {
+		t.Errorf("not found error = %v, want %v", err, ErrCompanyNotFound)
+	}
+
+	var inactiveErr *RecordInactiveError
+	if err := get("/inactive"); !errors.Is(err, ErrRecordInactive) || errors.Is(err, ErrCompanyNotFound) {
+		t.Errorf("inactive error = %v, want %v", err, ErrRecordInactive)
+	} else if !errors.As(err, &inactiveErr) || inactiveErr.InactiveDate == nil || !inactiveErr.InactiveDate.Equal(time.Date(2019, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("inactive error = %+v, want inactive since 2019-03-15", inactiveErr)
+	}
+
+	var statusErr *StatusError
+	if err := get("/throttled"); !errors.As(err, &statusErr) {
+		t.Errorf("throttled error = %#v, want *StatusError", err)
+	} else if statusErr.StatusCode != http.StatusTooManyRequests || statusErr.RetryAfter != 2*time.Minute || !statusErr.Temporary() {
+		t.Errorf("throttled error = %+v, want 429 retrying after 2m", statusErr)
+	}
+	if err := get("/bad-request"); !errors.As(err, &statusErr) {
+		t.Errorf("bad request error = %#v, want *StatusError", err)
+	} else if statusErr.Temporary() {
+		t.Errorf("bad request error %v should not be temporary", statusErr)
+	}
+
+	var parseErr *ParseError
+	if err := get("/layout"); !errors.As(err, &parseErr) {
+		t.Errorf("layout error = %#v, want *ParseError", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var transportErr *TransportError
+	_, err := NewClient(WithCompanySnapshotURL(ts.URL+"/not-found")).GetCompanyByDOTNumberContext(ctx, "1")
+	if !errors.As(err, &transportErr) || !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled error = %#v, want *TransportError wrapping context.Canceled", err)
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 10, 29, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		text string
+		want time.Duration
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "seconds", text: "30", want: 30 * time.Second},
+		{name: "http date", text: "Sat, 29 Oct 2022 12:01:00 GMT", want: time.Minute},
+		{name: "past date", text: "Sat, 29 Oct 2022 11:00:00 GMT", want: 0},
+		{name: "garbage", text: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.text, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseInactiveDate(t *testing.T) {
+	inactiveSince := time.Date(2019, 3, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		page string
+		want *time.Time
+	}{
+		// the page also has the date the information is as of and the last MCS-150 update
+		{name: "snapshot page", page: string(readTestData("./testdata/snapshot-inactive.html")), want: &inactiveSince},
+		{name: "inline", page: `<html><body><p>USDOT 1 INACTIVE since 03/15/2019</p></body></html>`, want: &inactiveSince},
+		{name: "no date", page: `<html><body><p>as of 10/28/2022</p><p>Record Inactive</p><p>Last MCS-150 update: 06/02/2017</p></body></html>`},
+		{name: "title only", page: `<html><head><title>SAFER Web - Company Snapshot RECORD INACTIVE</title></head><body><p>as of 10/28/2022</p></body></html>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := htmlquery.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := parseInactiveDate(root)
+			if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(*tt.want) {
+				t.Errorf("parseInactiveDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
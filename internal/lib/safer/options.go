@@ -0,0 +1,81 @@
+package safer
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Option configures a Client built by NewClient
+type Option func(*Client)
+
+// WithHTTPClient sends the requests through hc instead of http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTransport sends the requests through rt, e.g. to route them through a proxy
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		hc := http.Client{}
+		if c.httpClient != nil {
+			hc = *c.httpClient
+		}
+		hc.Transport = rt
+		c.httpClient = &hc
+	}
+}
+
+// WithBaseURL points the client at another host serving the SAFER pages, e.g. a local
+// mirror. The snapshot and search pages are expected at /query.asp and /keywordx.asp.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		c.companySnapshotURL = baseURL + "/query.asp"
+		c.searchURL = baseURL + "/keywordx.asp"
+	}
+}
+
+// WithCompanySnapshotURL overrides the URL of the company snapshot page
+func WithCompanySnapshotURL(u string) Option {
+	return func(c *Client) {
+		c.companySnapshotURL = u
+	}
+}
+
+// WithSearchURL overrides the URL of the company name search page
+func WithSearchURL(u string) Option {
+	return func(c *Client) {
+		c.searchURL = u
+	}
+}
+
+// WithUserAgent replaces the default browser User-Agent
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithHeader sets a header sent with every request, replacing any default value
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithRateLimiter throttles the client's requests with l instead of the limit set by
+// SetRateLimit
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(c *Client) {
+		c.limiter = l
+	}
+}
+
+// WithLogger logs every request at debug level to l instead of the standard logrus logger
+func WithLogger(l logrus.FieldLogger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
@@ -0,0 +1,134 @@
+package safer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+const notFoundHTML = `<html><head><title>SAFER Web - Company Snapshot RECORD NOT FOUND</title></head><body></body></html>`
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewClient_Options(t *testing.T) {
+	var got *http.Request
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(notFoundHTML))
+	}))
+	defer s.Close()
+
+	c := NewClient(
+		WithBaseURL(s.URL+"/"),
+		WithUserAgent("carrierleads-crawler/1.0"),
+		WithHeader("From", "ops@example.com"),
+	)
+	if _, err := c.GetCompanyByDOTNumber("1"); err != ErrCompanyNotFound {
+		t.Fatalf("GetCompanyByDOTNumber() error = %v, want %v", err, ErrCompanyNotFound)
+	}
+	if got.URL.Path != "/query.asp" {
+		t.Errorf("path = %q, want /query.asp", got.URL.Path)
+	}
+	if ua := got.Header.Get("User-Agent"); ua != "carrierleads-crawler/1.0" {
+		t.Errorf("User-Agent = %q, want carrierleads-crawler/1.0", ua)
+	}
+	if from := got.Header.Get("From"); from != "ops@example.com" {
+		t.Errorf("From = %q, want ops@example.com", from)
+	}
+
+	if _, err := c.SearchCompaniesByName("a"); err != nil {
+		t.Fatalf("SearchCompaniesByName() error = %v", err)
+	}
+	if got.URL.Path != "/keywordx.asp" {
+		t.Errorf("path = %q, want /keywordx.asp", got.URL.Path)
+	}
+
+	// options don't leak into the defaults
+	if ua := headers.Get("User-Agent"); ua == "carrierleads-crawler/1.0" {
+		t.Error("WithUserAgent modified the default headers")
+	}
+}
+
+func TestClient_escapesQueries(t *testing.T) {
+	var got *http.Request
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(notFoundHTML))
+	}))
+	defer s.Close()
+	c := NewClient(WithBaseURL(s.URL))
+
+	if _, err := c.SearchCompaniesByName("acme trucking & sons"); err != nil {
+		t.Fatalf("SearchCompaniesByName() error = %v", err)
+	}
+	if q := got.URL.Query().Get("searchstring"); q != "*ACME TRUCKING & SONS*" {
+		t.Errorf("searchstring = %q, want *ACME TRUCKING & SONS*", q)
+	}
+
+	if _, err := c.GetCompanyByMCMX("12 34&x"); err != ErrCompanyNotFound {
+		t.Fatalf("GetCompanyByMCMX() error = %v, want %v", err, ErrCompanyNotFound)
+	}
+	if q := got.URL.Query().Get("query_string"); q != "12 34&x" {
+		t.Errorf("query_string = %q, want %q", q, "12 34&x")
+	}
+}
+
+func TestNewClient_WithTransport(t *testing.T) {
+	var requests int
+	c := NewClient(WithTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		if r.URL.String() != companySnapshotURL+"?searchType=ANY&query_type=queryCarrierSnapshot&query_param=MC_MX&query_string=1" {
+			t.Errorf("unexpected request to %v", r.URL)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(notFoundHTML)),
+			Request:    r,
+		}, nil
+	})))
+	if _, err := c.GetCompanyByMCMX("1"); err != ErrCompanyNotFound {
+		t.Errorf("GetCompanyByMCMX() error = %v, want %v", err, ErrCompanyNotFound)
+	}
+	if requests != 1 {
+		t.Errorf("transport called %d times, want 1", requests)
+	}
+}
+
+func TestNewClient_WithLogger(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(notFoundHTML))
+	}))
+	defer s.Close()
+
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.DebugLevel)
+
+	c := NewClient(WithBaseURL(s.URL), WithLogger(logger))
+	if _, err := c.GetCompanyByDOTNumber("1"); err != ErrCompanyNotFound {
+		t.Fatalf("GetCompanyByDOTNumber() error = %v, want %v", err, ErrCompanyNotFound)
+	}
+	var entry struct {
+		Level      string `json:"level"`
+		URL        string `json:"url"`
+		StatusCode int    `json:"status_code"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log line %q: %v", out.String(), err)
+	}
+	if entry.Level != "debug" || entry.StatusCode != http.StatusOK || !strings.HasPrefix(entry.URL, s.URL+"/query.asp?") {
+		t.Errorf("log line = %+v", entry)
+	}
+}
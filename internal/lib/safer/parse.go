@@ -10,6 +10,7 @@ import (
 var (
 	dotSearchParamsRegex   = regexp.MustCompile(`query_string=([0-9]+)`)
 	mcs150MileageYearRegex = regexp.MustCompile(`([0-9,]+) \(([0-9]{4})\)`)
+	inactiveDateRegex      = regexp.MustCompile(`(?i)\binactive\b[\s.:]*(?:since|as of|date|on)?[\s:]*([0-9]{2}/[0-9]{2}/[0-9]{4})`)
 )
 
 func parseInt(text string) int {
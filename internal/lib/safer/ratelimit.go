@@ -0,0 +1,75 @@
+package safer
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLimiter throttles every request made to SAFER by any Client. It is nil,
+// meaning unlimited, until SetRateLimit is called.
+var defaultLimiter atomic.Pointer[RateLimiter]
+
+// SetRateLimit limits all requests made to SAFER to rps requests per second with
+// bursts of up to burst requests. A rps of 0 or less removes the limit.
+func SetRateLimit(rps float64, burst int) {
+	if rps <= 0 {
+		defaultLimiter.Store(nil)
+		return
+	}
+	defaultLimiter.Store(NewRateLimiter(rps, burst))
+}
+
+// RateLimiter is a token bucket refilled at a fixed rate
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter builds a token bucket allowing rps requests per second with bursts
+// of up to burst requests. The bucket starts full.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// take the token now, going into debt if needed, so that concurrent callers
+	// queue up behind each other instead of all waking at once
+	l.tokens--
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
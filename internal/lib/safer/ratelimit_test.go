@@ -0,0 +1,76 @@
+package safer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Burst(t *testing.T) {
+	l := NewRateLimiter(1, 3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("burst of 3 took %v, want no wait", elapsed)
+	}
+}
+
+func TestRateLimiter_Rate(t *testing.T) {
+	l := NewRateLimiter(50, 1)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	// the first token is free, the other 5 are spaced 20ms apart
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("6 requests at 50 rps took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestRateLimiter_Cancel(t *testing.T) {
+	l := NewRateLimiter(0.1, 1)
+	_ = l.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWithRequestTimeout_excludesRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(notFoundHTML))
+	}))
+	defer srv.Close()
+	// the second request waits 200ms for the limiter, longer than its timeout
+	c := NewClient(WithBaseURL(srv.URL), WithRateLimiter(NewRateLimiter(5, 1)))
+	for i := 0; i < 2; i++ {
+		ctx := WithRequestTimeout(context.Background(), 100*time.Millisecond)
+		if _, err := c.GetCompanyByDOTNumberContext(ctx, "1"); err != ErrCompanyNotFound {
+			t.Fatalf("request %d error = %v, want %v", i, err, ErrCompanyNotFound)
+		}
+	}
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(notFoundHTML))
+	}))
+	defer srv.Close()
+	c := NewClient(WithBaseURL(srv.URL))
+	ctx := WithRequestTimeout(context.Background(), 50*time.Millisecond)
+	if _, err := c.GetCompanyByDOTNumberContext(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -1,10 +1,18 @@
 package safer
 
+import "context"
+
 // NewClient build's a new Client interface
-func NewClient() *Client {
-	return &Client{
-		scraper: scraper{},
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		scraper: scraper{
+			header: headers.Clone(),
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 // Client for scraping company details from SAFER
@@ -15,7 +23,12 @@ type Client struct {
 // GetCompanyByDOTNumber - Get a company snapshot by the companies DOT number. Returns ErrCompanyNotFound if
 // no company is found
 func (c *Client) GetCompanyByDOTNumber(dotNumber string) (*CompanySnapshot, error) {
-	return c.scraper.scrapeCompanySnapshot(paramUSDOT, dotNumber)
+	return c.GetCompanyByDOTNumberContext(context.Background(), dotNumber)
+}
+
+// GetCompanyByDOTNumberContext - Same as GetCompanyByDOTNumber but the request is canceled when ctx is done.
+func (c *Client) GetCompanyByDOTNumberContext(ctx context.Context, dotNumber string) (*CompanySnapshot, error) {
+	return c.scraper.scrapeCompanySnapshot(ctx, paramUSDOT, dotNumber)
 }
 
 // GetCompanyByMCMX - Get a company snapshot by the companies MC/MX number. Returns ErrCompanyNotFound if no
@@ -23,11 +36,21 @@ func (c *Client) GetCompanyByDOTNumber(dotNumber string) (*CompanySnapshot, erro
 //
 // Note: do not include the prefix. (e.g. use "133655" not "MC-133655")
 func (c *Client) GetCompanyByMCMX(mcmx string) (*CompanySnapshot, error) {
-	return c.scraper.scrapeCompanySnapshot(paramMCMX, mcmx)
+	return c.GetCompanyByMCMXContext(context.Background(), mcmx)
+}
+
+// GetCompanyByMCMXContext - Same as GetCompanyByMCMX but the request is canceled when ctx is done.
+func (c *Client) GetCompanyByMCMXContext(ctx context.Context, mcmx string) (*CompanySnapshot, error) {
+	return c.scraper.scrapeCompanySnapshot(ctx, paramMCMX, mcmx)
 }
 
 // SearchCompaniesByName - Search for all carriers with a given name. Name queries will return the best matched results
 // in a slice of CompanyResult structs.
 func (c *Client) SearchCompaniesByName(name string) ([]CompanyResult, error) {
-	return c.scraper.scrapeCompanyNameSearch(name)
+	return c.SearchCompaniesByNameContext(context.Background(), name)
+}
+
+// SearchCompaniesByNameContext - Same as SearchCompaniesByName but the request is canceled when ctx is done.
+func (c *Client) SearchCompaniesByNameContext(ctx context.Context, name string) ([]CompanyResult, error) {
+	return c.scraper.scrapeCompanyNameSearch(ctx, name)
 }
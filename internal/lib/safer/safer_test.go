@@ -1,7 +1,12 @@
 package safer
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/antchfx/htmlquery"
 )
@@ -51,6 +56,34 @@ func TestClient_GetCompanyByDOTNumber(t *testing.T) {
 	}
 }
 
+func TestClient_Context(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer s.Close()
+	defer close(release)
+
+	c := &Client{
+		scraper: scraper{
+			companySnapshotURL: s.URL,
+			searchURL:          s.URL,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.GetCompanyByDOTNumberContext(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetCompanyByDOTNumberContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := c.GetCompanyByMCMXContext(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetCompanyByMCMXContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := c.SearchCompaniesByNameContext(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SearchCompaniesByNameContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
 func BenchmarkClient_GetCompanyByDOTNumber(b *testing.B) {
 	node, _ := htmlquery.LoadDoc("./testdata/snapshot-basic.html")
 	b.ReportAllocs()
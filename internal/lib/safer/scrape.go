@@ -1,11 +1,14 @@
 package safer
 
 import (
-	"errors"
+	"context"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/antchfx/htmlquery"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/net/html"
 )
 
@@ -30,47 +33,93 @@ var headers = http.Header{
 type scraper struct {
 	companySnapshotURL string
 	searchURL          string
+	httpClient         *http.Client
+	header             http.Header
+	limiter            *RateLimiter
+	logger             logrus.FieldLogger
 }
 
-func (s *scraper) scrapeCompanySnapshot(queryParam, queryString string) (*CompanySnapshot, error) {
-	params := "?searchType=ANY&query_type=queryCarrierSnapshot&query_param=" + queryParam + "&query_string=" + queryString
+func (s *scraper) scrapeCompanySnapshot(ctx context.Context, queryParam, queryString string) (*CompanySnapshot, error) {
+	params := "?searchType=ANY&query_type=queryCarrierSnapshot&query_param=" + queryParam + "&query_string=" + url.QueryEscape(queryString)
 	reqURL := companySnapshotURL
 	if s.companySnapshotURL != "" {
 		reqURL = s.companySnapshotURL
 	}
-	node, err := postRequestToHTMLNode(reqURL + params)
+	node, err := s.postRequestToHTMLNode(ctx, reqURL+params)
 	if err != nil {
 		return nil, err
 	}
 	return htmlNodeToCompanySnapshot(node)
 }
 
-func (s *scraper) scrapeCompanyNameSearch(queryString string) ([]CompanyResult, error) {
-	params := "?SEARCHTYPE=&searchstring=*" + strings.ToUpper(queryString) + "*"
+func (s *scraper) scrapeCompanyNameSearch(ctx context.Context, queryString string) ([]CompanyResult, error) {
+	params := "?SEARCHTYPE=&searchstring=*" + url.QueryEscape(strings.ToUpper(queryString)) + "*"
 	reqURL := searchURL
 	if s.searchURL != "" {
 		reqURL = s.searchURL
 	}
-	node, err := postRequestToHTMLNode(reqURL + params)
+	node, err := s.postRequestToHTMLNode(ctx, reqURL+params)
 	if err != nil {
 		return nil, err
 	}
 	return htmlNodeToCompanyResults(node)
 }
 
-func postRequestToHTMLNode(reqURL string) (*html.Node, error) {
-	req, err := http.NewRequest(http.MethodPost, reqURL, http.NoBody)
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout returns a copy of ctx bounding every request made with it to
+// timeout. Unlike a deadline set on ctx, the time spent waiting for the rate limit
+// doesn't count.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+func (s *scraper) postRequestToHTMLNode(ctx context.Context, reqURL string) (*html.Node, error) {
+	limiter := s.limiter
+	if limiter == nil {
+		limiter = defaultLimiter.Load()
+	}
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, http.NoBody)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = headers
-	resp, err := http.DefaultClient.Do(req)
+	req.Header = s.header
+	if req.Header == nil {
+		req.Header = headers
+	}
+	client := s.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	logger := s.logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	entry := logger.WithFields(logrus.Fields{"url": reqURL, "latency_ms": time.Since(start).Milliseconds()})
 	if err != nil {
-		return nil, err
+		entry.WithError(err).Debug("safer request failed")
+		return nil, &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
+	entry.WithField("status_code", resp.StatusCode).Debug("safer request")
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.Status + " Response from SAFER")
+		return nil, newStatusError(resp)
+	}
+	node, err := htmlquery.Parse(resp.Body)
+	if err != nil {
+		return nil, &ParseError{Err: err}
 	}
-	return htmlquery.Parse(resp.Body)
+	return node, nil
 }
@@ -1,6 +1,7 @@
 package safer
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -58,7 +59,7 @@ func TestScrapeSnapshot_Basic(t *testing.T) {
 	s := &scraper{
 		companySnapshotURL: ts.URL + "/snapshot",
 	}
-	snapshot, err := s.scrapeCompanySnapshot("", "")
+	snapshot, err := s.scrapeCompanySnapshot(context.Background(), "", "")
 	if err != nil {
 		t.Errorf("scrapeCompanySnapshot should return no error, but got %v", err)
 	}
@@ -113,7 +114,7 @@ func TestScrapeSnapshot_Extras(t *testing.T) {
 	s := &scraper{
 		companySnapshotURL: ts.URL + "/snapshot-extras",
 	}
-	snapshot, err := s.scrapeCompanySnapshot("", "")
+	snapshot, err := s.scrapeCompanySnapshot(context.Background(), "", "")
 	if err != nil {
 		t.Errorf("scrapeCompanySnapshot should return no error, but got %v", err)
 	}
@@ -165,7 +166,7 @@ func TestScrapeSnapshot_OOS(t *testing.T) {
 	s := &scraper{
 		companySnapshotURL: ts.URL + "/snapshot-oos",
 	}
-	snapshot, err := s.scrapeCompanySnapshot("", "")
+	snapshot, err := s.scrapeCompanySnapshot(context.Background(), "", "")
 	if err != nil {
 		t.Errorf("scrapeCompanySnapshot should return no error, but got %v", err)
 	}
@@ -219,7 +220,7 @@ func TestScrapeSnapshot_NotFound(t *testing.T) {
 	s := &scraper{
 		companySnapshotURL: ts.URL + "/snapshot-not-found",
 	}
-	snapshot, err := s.scrapeCompanySnapshot("", "")
+	snapshot, err := s.scrapeCompanySnapshot(context.Background(), "", "")
 	if err != ErrCompanyNotFound {
 		t.Errorf("scrapeCompanySnapshot should return ErrCompanyNotFound but got %v", err)
 	}
@@ -235,7 +236,7 @@ func TestScrapeSnapshot_Error(t *testing.T) {
 	s := &scraper{
 		companySnapshotURL: ts.URL + "/error",
 	}
-	snapshot, err := s.scrapeCompanySnapshot("a", "a")
+	snapshot, err := s.scrapeCompanySnapshot(context.Background(), "a", "a")
 	if err == nil {
 		t.Errorf("scrapeCompanySnapshot should return an error but got %v", err)
 	}
@@ -251,7 +252,7 @@ func TestScrapeCompanyNameSearch(t *testing.T) {
 	s := &scraper{
 		searchURL: ts.URL + "/search",
 	}
-	result, err := s.scrapeCompanyNameSearch("")
+	result, err := s.scrapeCompanyNameSearch(context.Background(), "")
 	if err != nil {
 		t.Errorf("scrapeCompanyNameSearch should return no error, but got %v", err)
 	}
@@ -271,7 +272,7 @@ func TestScrapeCompanyNameSearch_Error(t *testing.T) {
 	s := &scraper{
 		searchURL: ts.URL + "/error",
 	}
-	result, err := s.scrapeCompanyNameSearch("")
+	result, err := s.scrapeCompanyNameSearch(context.Background(), "")
 	if err == nil {
 		t.Errorf("scrapeCompanyNameSearch should return an error but got %v", err)
 	}
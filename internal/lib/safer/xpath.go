@@ -1,7 +1,10 @@
 package safer
 
 import (
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/antchfx/htmlquery"
 	"golang.org/x/net/html"
@@ -9,7 +12,9 @@ import (
 
 // company snapshot xpath constants
 const (
-	snapshotNotFoundXpath      = "/html/head/title[text()='SAFER Web - Company Snapshot RECORD NOT FOUND' or text()='SAFER Web - Company Snapshot RECORD INACTIVE']"
+	snapshotNotFoundXpath      = "/html/head/title[text()='SAFER Web - Company Snapshot RECORD NOT FOUND']"
+	snapshotInactiveXpath      = "/html/head/title[text()='SAFER Web - Company Snapshot RECORD INACTIVE']"
+	inactiveNoticeXpath        = "/html/body//text()[contains(translate(., 'INACTVE', 'inactve'), 'inactive')]"
 	srcTableXpath              = "/html/body/p/table/tbody/tr[2]/td/table/tbody/tr[2]/td"
 	latestUpdateDateXpath      = "/table/tbody/tr[3]/td/font/b[3]/font/text()"
 	tableGeneralInfoXpath      = "/center[1]/table/tbody"
@@ -28,11 +33,20 @@ const (
 	companyResultXpath = "/html/body/table[3]/tbody/tr[.//*[@scope='rpw']]"
 )
 
-func htmlNodeToCompanySnapshot(root *html.Node) (*CompanySnapshot, error) {
+func htmlNodeToCompanySnapshot(root *html.Node) (snapshot *CompanySnapshot, err error) {
 	if found := htmlquery.Find(root, snapshotNotFoundXpath); found != nil && len(found) > 0 {
 		return nil, ErrCompanyNotFound
 	}
-	snapshot := new(CompanySnapshot)
+	if found := htmlquery.Find(root, snapshotInactiveXpath); len(found) > 0 {
+		return nil, &RecordInactiveError{InactiveDate: parseInactiveDate(root)}
+	}
+	// the tables are indexed positionally, an unexpected layout may index out of range
+	defer func() {
+		if r := recover(); r != nil {
+			snapshot, err = nil, &ParseError{Err: fmt.Errorf("unexpected snapshot layout: %v", r)}
+		}
+	}()
+	snapshot = new(CompanySnapshot)
 	if srcNode := htmlquery.FindOne(root, srcTableXpath); srcNode != nil {
 		snapshot.LatestUpdateDate = parseDate(getNodeText(srcNode, latestUpdateDateXpath))
 		// general info
@@ -158,9 +172,30 @@ func htmlNodeToCompanySnapshot(root *html.Node) (*CompanySnapshot, error) {
 			}
 		}
 	}
+	if snapshot.DOTNumber == "" {
+		return nil, &ParseError{Err: errors.New("dot number not found in snapshot")}
+	}
 	return snapshot, nil
 }
 
+// parseInactiveDate returns the date of the notice telling the record is inactive,
+// e.g. "INACTIVE since 03/15/2019" or "<b>Inactive Date:</b> 03/15/2019". The other
+// dates of the page, such as the date the information is as of, are ignored. It
+// returns nil if the notice has no date.
+func parseInactiveDate(root *html.Node) *time.Time {
+	for _, notice := range htmlquery.Find(root, inactiveNoticeXpath) {
+		text := notice.Data
+		// the date may follow the element holding the notice, as the value of a label
+		if label := notice.Parent; label != nil && label.NextSibling != nil {
+			text += htmlquery.InnerText(label.NextSibling)
+		}
+		if match := inactiveDateRegex.FindStringSubmatch(text); match != nil {
+			return parseDate(match[1])
+		}
+	}
+	return nil
+}
+
 func htmlNodeToCompanyResults(node *html.Node) ([]CompanyResult, error) {
 	resultNodes := htmlquery.Find(node, companyResultXpath)
 	if resultNodes == nil || len(resultNodes) == 0 {
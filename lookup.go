@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"carrierleads.com/internal/lib/safer"
+	"carrierleads.com/internal/util"
+)
+
+// runGet prints the snapshot of a dot number, or of a docket number for the mc command
+func runGet(ctx context.Context, config Config, command string, args []string) error {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	synopsis := "get [flags] <dot>"
+	if command == "mc" {
+		synopsis = "mc [flags] <docket>"
+	}
+	number := parseFlags(fs, &config, args, 1, synopsis)[0]
+
+	safer.SetRateLimit(config.RateLimit, config.RateBurst)
+	client := newSaferClient(config)
+	var s *safer.CompanySnapshot
+	var err error
+	if command == "mc" {
+		s, err = client.GetCompanyByMCMXContext(ctx, number)
+	} else {
+		s, err = client.GetCompanyByDOTNumberContext(ctx, number)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(util.PrettyString(s))
+	return nil
+}
+
+func runSearch(ctx context.Context, config Config, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	name := parseFlags(fs, &config, args, 1, "search [flags] <name>")[0]
+
+	safer.SetRateLimit(config.RateLimit, config.RateBurst)
+	results, err := newSaferClient(config).SearchCompaniesByNameContext(ctx, name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(util.PrettyString(results))
+	return nil
+}
@@ -1,38 +1,232 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"carrierleads.com/internal/crawler"
 	"carrierleads.com/internal/dao"
+	"carrierleads.com/internal/lib/safer"
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	DBUrl        string `yaml:"db_url"`
-	DOTWatermark int    `yaml:"dot_watermark"`
-	BucketSize   int    `yaml:"bucket_size"`
+	DBUrl              string            `yaml:"db_url"`
+	DOTWatermark       int               `yaml:"dot_watermark"`
+	BucketSize         int               `yaml:"bucket_size"`
+	EmptyReprobe       time.Duration     `yaml:"empty_reprobe_interval"`
+	RefreshOrder       string            `yaml:"refresh_order"`
+	RefreshMaxAge      time.Duration     `yaml:"refresh_max_age"`
+	RefreshBudget      int               `yaml:"refresh_budget"`
+	DocketStart        int               `yaml:"docket_start"`
+	DocketEnd          int               `yaml:"docket_end"`
+	DocketMaxAge       time.Duration     `yaml:"docket_max_age"`
+	Keywords           []string          `yaml:"search_keywords"`
+	PrefixLength       int               `yaml:"search_prefix_length"`
+	RateLimit          float64           `yaml:"rate_limit"`
+	RateBurst          int               `yaml:"rate_burst"`
+	MinConns           int               `yaml:"min_connections"`
+	MaxConns           int               `yaml:"max_connections"`
+	SaferURL           string            `yaml:"safer_url"`
+	ProxyURL           string            `yaml:"proxy_url"`
+	UserAgent          string            `yaml:"user_agent"`
+	Headers            map[string]string `yaml:"headers"`
+	MetricsAddr        string            `yaml:"metrics_addr"`
+	LogFormat          string            `yaml:"log_format"`
+	LogLevel           string            `yaml:"log_level"`
+	Sinks              []SinkConfig      `yaml:"sinks"`
+	WriteBatchSize     int               `yaml:"write_batch_size"`
+	WriteBatchInterval time.Duration     `yaml:"write_batch_interval"`
+	Webhooks           []WebhookConfig   `yaml:"webhooks"`
 }
 
+// bindFlags lets the flags of a command override the fields shared by every command
+func (c *Config) bindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.DBUrl, "db-url", c.DBUrl, "mysql connection string")
+	fs.Float64Var(&c.RateLimit, "rate-limit", c.RateLimit, "requests per second sent to SAFER, 0 for no limit")
+	fs.IntVar(&c.RateBurst, "rate-burst", c.RateBurst, "requests allowed in a burst")
+	fs.IntVar(&c.MinConns, "min-connections", c.MinConns, "initial number of concurrent requests to SAFER")
+	fs.IntVar(&c.MaxConns, "max-connections", c.MaxConns, "maximum number of concurrent requests to SAFER")
+	fs.StringVar(&c.SaferURL, "safer-url", c.SaferURL, "base URL of the SAFER pages")
+	fs.StringVar(&c.ProxyURL, "proxy-url", c.ProxyURL, "proxy the requests to SAFER go through")
+	fs.StringVar(&c.UserAgent, "user-agent", c.UserAgent, "User-Agent sent to SAFER")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "log format, either logfmt or json")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "lowest level logged, e.g. debug to log every carrier stored")
+}
+
+// configureLogging sets the format and level of the standard logger
+func configureLogging(format, level string) error {
+	switch format {
+	case "logfmt":
+		log.SetFormatter(&log.TextFormatter{DisableColors: true, FullTimestamp: true})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	l, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(l)
+	return nil
+}
+
+const usage = `Usage: carrierleads [--config config.yaml] <command> [flags] [args]
+
+Commands:
+  crawl            crawl SAFER into the database, see crawl --help for the modes
+  get <dot>        print the SAFER snapshot of a dot number
+  mc <docket>      print the SAFER snapshot of an MC/MX docket number
+  search <name>    print the carriers whose name matches on SAFER
+  export           write the stored carriers as JSON lines or CSV
+  history <dot>    print the stored versions of a carrier
+  events           print the carrier events detected by the crawls
+  stats            print a summary of the database
+`
+
 func main() {
-	config := readConfig()
-	dao := dao.Instance(config.DBUrl)
-	err := crawler.CrawlSafer(config.DOTWatermark, config.BucketSize, 50, dao)
+	configPath := flag.String("config", "config.yaml", "path to the config file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	explicit := false
+	flag.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "config"
+	})
+	config := readConfig(*configPath, explicit)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		log.Info("shutting down, interrupt again to exit immediately")
+		stop()
+	}()
+
+	var err error
+	command, args := flag.Arg(0), flag.Args()[1:]
+	switch command {
+	case "crawl":
+		err = runCrawl(ctx, config, args)
+	case "get", "mc":
+		err = runGet(ctx, config, command, args)
+	case "search":
+		err = runSearch(ctx, config, args)
+	case "export":
+		err = runExport(ctx, config, args)
+	case "stats":
+		err = runStats(ctx, config, args)
+	case "history":
+		err = runHistory(ctx, config, args)
+	case "events":
+		err = runEvents(ctx, config, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", command)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if errors.Is(err, context.Canceled) {
+		os.Exit(130)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-func readConfig() (c Config) {
-	f, err := os.ReadFile("config.yaml") // just pass the file name
+// parseFlags parses the flags of a command, overriding the config with them, and
+// returns the remaining arguments. It exits if the number of arguments isn't nargs,
+// or isn't checked if nargs is negative.
+func parseFlags(fs *flag.FlagSet, config *Config, args []string, nargs int, synopsis string) []string {
+	config.bindFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: carrierleads %s\n\n", synopsis)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if nargs >= 0 && fs.NArg() != nargs {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := configureLogging(config.LogFormat, config.LogLevel); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		os.Exit(2)
+	}
+	return fs.Args()
+}
+
+// newCrawler builds a crawler writing to the database. It has no database if db_url
+// isn't set.
+func newCrawler(config Config) *crawler.Crawler {
+	safer.SetRateLimit(config.RateLimit, config.RateBurst)
+	c := &crawler.Crawler{
+		Client:      newSaferClient(config),
+		Concurrency: crawler.NewConcurrencyController(config.MinConns, config.MaxConns),
+	}
+	if config.DBUrl != "" {
+		c.Dao = dao.Instance(config.DBUrl)
+	}
+	return c
+}
+
+func newSaferClient(config Config) *safer.Client {
+	var opts []safer.Option
+	if config.SaferURL != "" {
+		opts = append(opts, safer.WithBaseURL(config.SaferURL))
+	}
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			log.WithError(err).Fatal("invalid proxy url")
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxy)
+		opts = append(opts, safer.WithTransport(transport))
+	}
+	if config.UserAgent != "" {
+		opts = append(opts, safer.WithUserAgent(config.UserAgent))
+	}
+	for key, value := range config.Headers {
+		opts = append(opts, safer.WithHeader(key, value))
+	}
+	return safer.NewClient(opts...)
+}
+
+// readConfig reads the config file at path. A missing file is only an error if its
+// path was given explicitly, the defaults are used otherwise.
+func readConfig(path string, explicit bool) (c Config) {
+	c.BucketSize, c.MinConns, c.MaxConns = 100, 5, 50
+	c.EmptyReprobe = 30 * 24 * time.Hour
+	c.DocketStart, c.DocketEnd, c.DocketMaxAge = 1, 1600000, 30*24*time.Hour
+	c.LogFormat, c.LogLevel = "logfmt", "info"
+	c.WriteBatchSize, c.WriteBatchInterval = 100, time.Second
+
+	f, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return
+	}
 	if err != nil {
-		log.Fatalf("failed to find config file %v", err)
+		log.WithError(err).Fatal("failed to find config file")
 	}
 
 	err = yaml.Unmarshal(f, &c)
 	if err != nil {
-		log.Fatalf("error: %v", err)
+		log.WithError(err).Fatal("failed to parse config file")
 	}
 
 	return
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"carrierleads.com/internal/crawler"
+)
+
+// SinkConfig selects where the crawled carriers are written: the database for the
+// mysql type, or the file at Path for the jsonl and csv types
+type SinkConfig struct {
+	Type string `yaml:"type"`
+	Path string `yaml:"path"`
+}
+
+var errNoDatabase = errors.New("db_url isn't set")
+
+// openSink opens the sinks of config, fanning out to them if there are several. The
+// carriers are written to the database if no sink is configured.
+func openSink(config Config, c *crawler.Crawler) (sink crawler.Sink, err error) {
+	configs := config.Sinks
+	if len(configs) == 0 {
+		configs = []SinkConfig{{Type: "mysql"}}
+	}
+	var sinks []crawler.Sink
+	defer func() {
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+		}
+	}()
+	for _, sc := range configs {
+		var s crawler.Sink
+		switch sc.Type {
+		case "mysql":
+			if config.DBUrl == "" {
+				return nil, errNoDatabase
+			}
+			s = crawler.MySQLSink{Dao: c.Dao, Notifier: c.Notifier}
+			if config.WriteBatchSize > 1 {
+				s = c.NewBatchSink(config.WriteBatchSize, config.WriteBatchInterval)
+			}
+		case "jsonl", "csv":
+			if sc.Path == "" {
+				return nil, fmt.Errorf("%s sink has no path", sc.Type)
+			}
+			if s, err = openFileSink(sc.Type, sc.Path); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown sink type %q", sc.Type)
+		}
+		sinks = append(sinks, s)
+	}
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return crawler.MultiSink(sinks...), nil
+}
+
+// openFileSink creates the file at path, or uses stdout for -, and returns a sink
+// writing to it in format
+func openFileSink(format, path string) (crawler.Sink, error) {
+	if format != "jsonl" && format != "csv" {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	f := os.Stdout
+	if path != "-" {
+		var err error
+		if f, err = os.Create(path); err != nil {
+			return nil, err
+		}
+	}
+	var s crawler.Sink = crawler.NewJSONLSink(f)
+	if format == "csv" {
+		var err error
+		if s, err = crawler.NewCSVSink(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if f == os.Stdout {
+		return s, nil
+	}
+	return fileSink{Sink: s, f: f}, nil
+}
+
+// fileSink closes the file written by a sink along with the sink
+type fileSink struct {
+	crawler.Sink
+	f *os.File
+}
+
+func (s fileSink) Close() error {
+	err := s.Sink.Close()
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
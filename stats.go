@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"carrierleads.com/internal/dao"
+)
+
+func runStats(ctx context.Context, config Config, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	runs := fs.Int("runs", 5, "number of recent crawl runs summarized")
+	parseFlags(fs, &config, args, 0, "stats [flags]")
+	if config.DBUrl == "" {
+		return errNoDatabase
+	}
+	d := dao.Instance(config.DBUrl)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	statuses, err := d.Queries.CountSnapshotsByOperatingStatus(ctx)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, status := range statuses {
+		total += status.Carriers
+	}
+	fmt.Fprintf(w, "carriers\t%d\n", total)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "  %s\t%d\n", status.OperatingStatus, status.Carriers)
+	}
+
+	highest, err := d.Queries.GetMaxDotNumber(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	fmt.Fprintf(w, "highest dot number\t%d\n", highest)
+
+	ranges, err := d.Queries.CountEmptyDotRanges(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "empty dot ranges\t%d\n", ranges)
+
+	failures, err := d.Queries.CountCrawlFailures(ctx)
+	if err != nil {
+		return err
+	}
+	total = 0
+	for _, failure := range failures {
+		total += failure.Failures
+	}
+	fmt.Fprintf(w, "failures\t%d\n", total)
+	for _, failure := range failures {
+		fmt.Fprintf(w, "  %s %s\t%d\n", failure.Stage, failure.ErrorClass, failure.Failures)
+	}
+
+	docketFailures, err := d.Queries.CountDocketFailures(ctx)
+	if err != nil {
+		return err
+	}
+	total = 0
+	for _, failure := range docketFailures {
+		total += failure.Failures
+	}
+	fmt.Fprintf(w, "docket failures\t%d\n", total)
+	for _, failure := range docketFailures {
+		fmt.Fprintf(w, "  %s %s\t%d\n", failure.Stage, failure.ErrorClass, failure.Failures)
+	}
+
+	checkpoints, err := d.Queries.ListCrawlCheckpoints(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range checkpoints {
+		fmt.Fprintf(w, "checkpoint %s\tat %d, saved %s\n", c.Name, c.Watermark, time.Unix(int64(c.UpdatedAt), 0).Format(time.RFC3339))
+	}
+	if *runs <= 0 {
+		return nil
+	}
+	w.Flush()
+
+	recent, err := d.Queries.ListRecentCrawlRuns(ctx, int32(*runs))
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "run\tmode\tstarted\tduration\tdots\tfound\tnot found\tfailed\twritten\trequests\tretries\texit")
+	for _, r := range recent {
+		started := time.Unix(int64(r.StartedAt), 0)
+		duration, exit := "-", r.ExitReason
+		if r.EndedAt > 0 {
+			duration = time.Unix(int64(r.EndedAt), 0).Sub(started).String()
+		} else {
+			// still crawling, or the process died before recording the end
+			exit = "unfinished"
+		}
+		dots := "-"
+		if r.LastDot > 0 {
+			dots = fmt.Sprintf("%d-%d", r.FirstDot, r.LastDot)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			r.ID, r.Mode, started.Format(time.RFC3339), duration, dots,
+			r.Found, r.NotFound, r.Failed, r.Written, r.Requests, r.Retries, exit)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"carrierleads.com/internal/crawler"
+)
+
+// WebhookConfig is an endpoint the carrier events detected by a crawl are POSTed to.
+// Only the events listed in Events are sent, or all of them if it is empty.
+type WebhookConfig struct {
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"`
+	Events []string `yaml:"events"`
+}
+
+// webhooks validates the webhooks of config
+func webhooks(config Config) ([]crawler.Webhook, error) {
+	var hooks []crawler.Webhook
+	for _, wc := range config.Webhooks {
+		u, err := url.Parse(wc.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid webhook url %q", wc.URL)
+		}
+		hook := crawler.Webhook{URL: wc.URL, Secret: wc.Secret}
+		for _, e := range wc.Events {
+			if !knownEventType(e) {
+				return nil, fmt.Errorf("unknown event type %q for webhook %s, expected one of %v", e, wc.URL, crawler.EventTypes)
+			}
+			hook.Events = append(hook.Events, crawler.EventType(e))
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
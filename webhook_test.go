@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"carrierleads.com/internal/crawler"
+)
+
+func Test_webhooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []WebhookConfig
+		want    []crawler.Webhook
+		wantErr bool
+	}{
+		{name: "none"},
+		{
+			name:    "filtered",
+			configs: []WebhookConfig{{URL: "https://crm.example.com/hooks", Secret: "s3cret", Events: []string{"out_of_service"}}},
+			want:    []crawler.Webhook{{URL: "https://crm.example.com/hooks", Secret: "s3cret", Events: []crawler.EventType{crawler.EventOutOfService}}},
+		},
+		{name: "unknown event", configs: []WebhookConfig{{URL: "https://crm.example.com/hooks", Events: []string{"renamed"}}}, wantErr: true},
+		{name: "no scheme", configs: []WebhookConfig{{URL: "crm.example.com/hooks"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := webhooks(Config{Webhooks: tt.configs})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("webhooks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("webhooks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}